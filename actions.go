@@ -0,0 +1,113 @@
+package vorl
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ListAction is run on the list item under the cursor when its key is
+// pressed in interactive mode. Keys used by the list itself, as '/' or 'q',
+// cannot be used: the command fails if one of them is.
+type ListAction struct {
+	Key  string
+	Name string
+
+	// Confirm asks the user before running the action.
+	Confirm bool
+
+	// Run receives the value of the item, as OnSelect does.
+	Run func(selected string) interface{}
+}
+
+// TableAction is run on the table row under the cursor when its key is
+// pressed in interactive mode. As with ListAction, the keys used by the table
+// itself cannot be used.
+type TableAction struct {
+	Key  string
+	Name string
+
+	// Confirm asks the user before running the action.
+	Confirm bool
+
+	Run func(selected []string) interface{}
+}
+
+// checkActions replaces a result by an error if one of its actions is bound
+// to a key used by interactive results, or by another action, since it would
+// never run. Live results are stopped, as in checkResult.
+func checkActions(result tea.Msg, km KeyMap) tea.Msg {
+	keys := []string{}
+	var stop func()
+
+	switch result := result.(type) {
+	case CommandResultList:
+		for _, action := range result.Actions {
+			keys = append(keys, action.Key)
+		}
+	case CommandResultPagedList:
+		for _, action := range result.Actions {
+			keys = append(keys, action.Key)
+		}
+	case CommandResultLiveList:
+		for _, action := range result.Actions {
+			keys = append(keys, action.Key)
+		}
+		stop = result.Stop
+	case CommandResultTable:
+		for _, action := range result.Actions {
+			keys = append(keys, action.Key)
+		}
+	case CommandResultPagedTable:
+		for _, action := range result.Actions {
+			keys = append(keys, action.Key)
+		}
+	case CommandResultLiveTable:
+		for _, action := range result.Actions {
+			keys = append(keys, action.Key)
+		}
+		stop = result.Stop
+	}
+
+	owners := map[string]string{}
+	for _, b := range km.interactionBindings() {
+		if !b.binding.Enabled() {
+			continue
+		}
+		for _, k := range b.binding.Keys() {
+			owners[k] = b.name
+		}
+	}
+
+	for _, k := range keys {
+		if owner, ok := owners[k]; ok {
+			if stop != nil {
+				stop()
+			}
+			return commandError(fmt.Errorf("the key %q of an action is bound to %s", k, owner))
+		}
+		owners[k] = "another action"
+	}
+
+	return result
+}
+
+func actionBinding(k string, name string) key.Binding {
+	return key.NewBinding(
+		key.WithKeys(k),
+		key.WithHelp(k, name),
+	)
+}
+
+// pendingAction is an action waiting for the user to confirm it.
+type pendingAction struct {
+	name  string
+	label string
+	run   func() interface{}
+}
+
+// View asks to press the first key of the binding that confirms the action.
+func (a pendingAction) View(confirm key.Binding) string {
+	return fmt.Sprintf("run %s? [%s/N]", a.name, confirm.Keys()[0])
+}
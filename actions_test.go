@@ -0,0 +1,49 @@
+package vorl
+
+import (
+	"testing"
+)
+
+func TestCheckActions(t *testing.T) {
+	stopped := false
+	run := func(string) interface{} { return nil }
+	runRow := func([]string) interface{} { return nil }
+
+	tests := []struct {
+		name   string
+		result interface{}
+		valid  bool
+	}{
+		{"free key", CommandResultList{Actions: []ListAction{{Key: "x", Run: run}}}, true},
+		{"list key", CommandResultList{Actions: []ListAction{{Key: "q", Run: run}}}, false},
+		{"navigation key", CommandResultTable{Actions: []TableAction{{Key: "d", Run: runRow}}}, false},
+		{"copy key", CommandResultPagedTable{Actions: []TableAction{{Key: "y", Run: runRow}}}, false},
+		{
+			"repeated key",
+			CommandResultPagedList{Actions: []ListAction{{Key: "x", Run: run}, {Key: "x", Run: run}}},
+			false,
+		},
+		{
+			"live list",
+			CommandResultLiveList{
+				Actions: []ListAction{{Key: "a", Run: run}},
+				Stop:    func() { stopped = true },
+			},
+			false,
+		},
+		{"no actions", CommandResultSimple("done"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, failed := checkActions(tt.result, DefaultKeyMap()).(commandError)
+			if failed == tt.valid {
+				t.Errorf("checkActions() failed = %v, want %v", failed, !tt.valid)
+			}
+		})
+	}
+
+	if !stopped {
+		t.Error("the live list with an invalid action was not stopped")
+	}
+}
@@ -0,0 +1,102 @@
+package vorl
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// chainStep is a command of a command line, and the operator before it:
+// "" for the first one, "&&", "||" or ";".
+type chainStep struct {
+	op      string
+	command string
+}
+
+// parseChain splits a command line in the commands joined by unquoted '&&',
+// '||' and ';' operators, as found by Tokenize.
+func parseChain(input string) []chainStep {
+	steps := []chainStep{}
+	op, start := "", 0
+
+	addStep := func(end int, next string) {
+		if command := strings.TrimSpace(input[start:end]); command != "" {
+			steps = append(steps, chainStep{op: op, command: command})
+		}
+		op = next
+	}
+
+	for _, token := range Tokenize(input, -1).Tokens {
+		if isOperator(token) {
+			addStep(token.Start, token.Value)
+			start = token.End
+		}
+	}
+	addStep(len(input), "")
+
+	return steps
+}
+
+// nextSteps drops the steps that are not run after a command, as a shell
+// does: '&&' runs a command only after a success, '||' only after a failure
+// and ';' always. It returns nil if there is nothing left to run.
+func nextSteps(steps []chainStep, failed bool) []chainStep {
+	for i, step := range steps {
+		if step.op == ";" || (step.op == "&&" && !failed) || (step.op == "||" && failed) {
+			return steps[i:]
+		}
+	}
+
+	return nil
+}
+
+// runSteps runs the first step of a command line in the background. The
+// rest are run by the model once its result is shown.
+func runSteps(interpreter Interpreter, steps []chainStep, redirection bool) tea.Cmd {
+	return func() tea.Msg {
+		command, file, redirected := steps[0].command, "", false
+		if redirection {
+			command, file, redirected = parseRedirection(command)
+		}
+
+		finished := commandFinished{
+			result: runCommand(interpreter, command),
+			chain:  steps[1:],
+		}
+		if redirected {
+			finished.redirectTo = file
+		}
+
+		return finished
+	}
+}
+
+// runChainedCommand runs the next step of the command line if the REPL is
+// waiting for input, printing the result of the previous one if it is
+// still shown.
+func (m model) runChainedCommand() (model, tea.Cmd) {
+	if len(m.chain) == 0 {
+		return m, nil
+	}
+
+	switch m.state {
+	case replStateReadingInput,
+		replStateReadingInputAndList,
+		replStateReadingInputAndTable,
+		replStateReadingInputAndTree:
+
+	default:
+		return m, nil
+	}
+
+	steps := m.chain
+	m.chain = nil
+
+	var printResult tea.Cmd
+	m, printResult = m.dropResult()
+	m.state = replStateExecutingCommand
+	m.commandStart = time.Now()
+
+	return m, tea.Sequence(printResult, runSteps(m.interpreter, steps, m.redirection))
+}
@@ -0,0 +1,79 @@
+package vorl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseChain(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []chainStep
+	}{
+		{"", []chainStep{}},
+		{"ls -l", []chainStep{{"", "ls -l"}}},
+		{"a && b || c ; d", []chainStep{{"", "a"}, {"&&", "b"}, {"||", "c"}, {";", "d"}}},
+		{"ls; pwd", []chainStep{{"", "ls"}, {";", "pwd"}}},
+		{"ls > f && cat f", []chainStep{{"", "ls > f"}, {"&&", "cat f"}}},
+		{"echo '&&' b", []chainStep{{"", "echo '&&' b"}}},
+		{`echo "a;" b`, []chainStep{{"", `echo "a;" b`}}},
+		{"ls ;", []chainStep{{"", "ls"}}},
+		{"&& ls", []chainStep{{"&&", "ls"}}},
+		{`x "y"; z`, []chainStep{{"", `x "y"`}, {";", "z"}}},
+		{`cd "my dir"; ls`, []chainStep{{"", `cd "my dir"`}, {";", "ls"}}},
+		{"a;b", []chainStep{{"", "a"}, {";", "b"}}},
+		{"a&&b||c", []chainStep{{"", "a"}, {"&&", "b"}, {"||", "c"}}},
+		{`echo a\;b`, []chainStep{{"", `echo a\;b`}}},
+		{"a & b | c", []chainStep{{"", "a & b | c"}}},
+	}
+
+	for _, tt := range tests {
+		if got := parseChain(tt.input); !slices.Equal(got, tt.want) {
+			t.Errorf("parseChain(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNextSteps(t *testing.T) {
+	steps := []chainStep{{"&&", "and"}, {"||", "or"}, {";", "always"}}
+
+	tests := []struct {
+		name   string
+		steps  []chainStep
+		failed bool
+		want   []chainStep
+	}{
+		{"success runs and", steps, false, steps},
+		{"failure skips and", steps, true, steps[1:]},
+		{"success skips or", steps[1:], false, steps[2:]},
+		{"failure runs or", steps[1:], true, steps[1:]},
+		{"always", steps[2:], true, steps[2:]},
+		{"nothing left", steps[:2], false, steps[:2]},
+		{"nothing to run", []chainStep{{"||", "or"}}, false, nil},
+		{"empty", nil, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextSteps(tt.steps, tt.failed); !slices.Equal(got, tt.want) {
+				t.Errorf("nextSteps = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectedBuiltinIsApplied(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModel, _ := r.model.Update(commandFinished{
+		result:     setEditingMode("vi"),
+		redirectTo: "out.txt",
+	})
+
+	if !newModel.(model).textInput.vi.enabled {
+		t.Errorf("the editing mode was not changed")
+	}
+}
@@ -0,0 +1,269 @@
+package vorl
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const killRingSize = 30
+
+type editAction int
+
+const (
+	editActionNone editAction = iota
+	editActionInsert
+	editActionKill
+	editActionYank
+)
+
+// inputSnapshot is a state of the input that can be restored with undo.
+type inputSnapshot struct {
+	value []rune
+	pos   int
+}
+
+// editingState holds the readline style editing state of the input: the
+// kill ring, the undo stack and what the last edit was, since consecutive
+// kills are merged and yank-pop only works right after a yank.
+type editingState struct {
+	killRing  []string
+	killIndex int
+
+	yankStart int
+
+	undoStack []inputSnapshot
+
+	lastAction editAction
+}
+
+// editingUpdate handles the readline editing keys that textinput does not
+// provide. Word motions and character deletions are left to textinput. It
+// returns false if the key was not handled.
+func (ri replInput) editingUpdate(msg tea.KeyMsg) (replInput, bool) {
+	value := []rune(ri.textInput.Value())
+	pos := ri.textInput.Position()
+
+	switch {
+	case key.Matches(msg, ri.keyMap.KillWordBackward):
+		start := wordStartBackward(value, pos)
+		ri = ri.kill(value, start, pos, true)
+
+	case key.Matches(msg, ri.keyMap.KillWordForward):
+		end := wordEndForward(value, pos)
+		ri = ri.kill(value, pos, end, false)
+
+	case key.Matches(msg, ri.keyMap.KillToEnd):
+		ri = ri.kill(value, pos, len(value), false)
+
+	case key.Matches(msg, ri.keyMap.KillToStart):
+		ri = ri.kill(value, 0, pos, true)
+
+	case key.Matches(msg, ri.keyMap.Yank):
+		if len(ri.editing.killRing) == 0 {
+			break
+		}
+		ri.editing.killIndex = len(ri.editing.killRing) - 1
+		ri = ri.yank(value, pos, pos)
+
+	case key.Matches(msg, ri.keyMap.YankPop):
+		if ri.editing.lastAction != editActionYank || len(ri.editing.killRing) == 0 {
+			break
+		}
+		// replace the text just yanked with the previous kill, starting from
+		// the state saved before the yank
+		ri.editing.killIndex--
+		if ri.editing.killIndex < 0 {
+			ri.editing.killIndex = len(ri.editing.killRing) - 1
+		}
+		prev := ri.editing.undoStack[len(ri.editing.undoStack)-1]
+		ri.editing.undoStack = ri.editing.undoStack[:len(ri.editing.undoStack)-1]
+		ri.textInput.SetCursor(prev.pos)
+		ri = ri.yank(prev.value, ri.editing.yankStart, ri.editing.yankStart)
+
+	case key.Matches(msg, ri.keyMap.Transpose):
+		if len(value) < 2 || pos == 0 {
+			break
+		}
+		// at the end of the line transpose the last two characters
+		if pos == len(value) {
+			pos--
+		}
+		newValue := append([]rune{}, value...)
+		newValue[pos-1], newValue[pos] = newValue[pos], newValue[pos-1]
+		ri = ri.setValue(newValue, pos+1, value, pos, editActionNone)
+
+	case key.Matches(msg, ri.keyMap.UpperCaseWord):
+		ri = ri.changeWordCase(value, pos, strings.ToUpper)
+
+	case key.Matches(msg, ri.keyMap.LowerCaseWord):
+		ri = ri.changeWordCase(value, pos, strings.ToLower)
+
+	case key.Matches(msg, ri.keyMap.CapitalizeWord):
+		ri = ri.changeWordCase(value, pos, func(word string) string {
+			w := []rune(strings.ToLower(word))
+			for i, r := range w {
+				if !unicode.IsSpace(r) {
+					w[i] = unicode.ToUpper(r)
+					break
+				}
+			}
+			return string(w)
+		})
+
+	case key.Matches(msg, ri.keyMap.Undo):
+		ri = ri.undo()
+
+	default:
+		return ri, false
+	}
+
+	return ri, true
+}
+
+// undo restores the state of the input before the last edit.
+func (ri replInput) undo() replInput {
+	if len(ri.editing.undoStack) == 0 {
+		return ri
+	}
+
+	last := ri.editing.undoStack[len(ri.editing.undoStack)-1]
+	ri.editing.undoStack = ri.editing.undoStack[:len(ri.editing.undoStack)-1]
+	ri.textInput.SetValue(string(last.value))
+	ri.textInput.SetCursor(last.pos)
+	ri.editing.lastAction = editActionNone
+
+	return ri
+}
+
+// kill removes value[start:end] and saves it in the kill ring. Consecutive
+// kills are merged in a single entry.
+func (ri replInput) kill(value []rune, start int, end int, backward bool) replInput {
+	if start >= end {
+		return ri
+	}
+
+	killed := string(value[start:end])
+	ring := ri.editing.killRing
+	if ri.editing.lastAction == editActionKill && len(ring) > 0 {
+		if backward {
+			ring[len(ring)-1] = killed + ring[len(ring)-1]
+		} else {
+			ring[len(ring)-1] += killed
+		}
+	} else {
+		ring = append(ring, killed)
+		if len(ring) > killRingSize {
+			ring = ring[1:]
+		}
+	}
+	ri.editing.killRing = ring
+
+	newValue := append(append([]rune{}, value[:start]...), value[end:]...)
+	return ri.setValue(newValue, start, value, ri.textInput.Position(), editActionKill)
+}
+
+// yank replaces value[start:end] with the current kill ring entry.
+func (ri replInput) yank(value []rune, start int, end int) replInput {
+	text := []rune(ri.editing.killRing[ri.editing.killIndex])
+
+	newValue := append([]rune{}, value[:start]...)
+	newValue = append(newValue, text...)
+	newValue = append(newValue, value[end:]...)
+
+	ri = ri.setValue(newValue, start+len(text), value, ri.textInput.Position(), editActionYank)
+	ri.editing.yankStart = start
+
+	return ri
+}
+
+// changeWordCase applies fn to the text from the cursor to the end of the
+// word, and moves the cursor after it.
+func (ri replInput) changeWordCase(value []rune, pos int, fn func(string) string) replInput {
+	end := wordEndForward(value, pos)
+	if pos == end {
+		return ri
+	}
+
+	newValue := append([]rune{}, value[:pos]...)
+	newValue = append(newValue, []rune(fn(string(value[pos:end])))...)
+	newValue = append(newValue, value[end:]...)
+
+	return ri.setValue(newValue, end, value, pos, editActionNone)
+}
+
+// setValue changes the input saving the previous state in the undo stack.
+func (ri replInput) setValue(
+	value []rune,
+	pos int,
+	prevValue []rune,
+	prevPos int,
+	action editAction,
+) replInput {
+	ri.pushUndo(prevValue, prevPos)
+	ri.textInput.SetValue(string(value))
+	ri.textInput.SetCursor(pos)
+	ri.editing.lastAction = action
+
+	return ri
+}
+
+// trackEdit records an edit done by textinput itself, so it can be undone.
+// Consecutive insertions are undone together.
+func (ri replInput) trackEdit(prevValue []rune, prevPos int, msg tea.Msg) replInput {
+	if string(prevValue) == ri.textInput.Value() {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			ri.editing.lastAction = editActionNone
+		}
+		return ri
+	}
+
+	action := editActionNone
+	if msg, ok := msg.(tea.KeyMsg); ok && (msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace) {
+		action = editActionInsert
+	}
+
+	if action != editActionInsert || ri.editing.lastAction != editActionInsert {
+		ri.pushUndo(prevValue, prevPos)
+	}
+	ri.editing.lastAction = action
+
+	return ri
+}
+
+func (ri *replInput) pushUndo(value []rune, pos int) {
+	ri.editing.undoStack = append(ri.editing.undoStack, inputSnapshot{
+		value: append([]rune{}, value...),
+		pos:   pos,
+	})
+}
+
+// wordStartBackward returns the position of the start of the word before
+// pos, skipping the spaces between them.
+func wordStartBackward(value []rune, pos int) int {
+	i := pos
+	for i > 0 && unicode.IsSpace(value[i-1]) {
+		i--
+	}
+	for i > 0 && !unicode.IsSpace(value[i-1]) {
+		i--
+	}
+
+	return i
+}
+
+// wordEndForward returns the position of the end of the word after pos,
+// skipping the spaces between them.
+func wordEndForward(value []rune, pos int) int {
+	i := pos
+	for i < len(value) && unicode.IsSpace(value[i]) {
+		i++
+	}
+	for i < len(value) && !unicode.IsSpace(value[i]) {
+		i++
+	}
+
+	return i
+}
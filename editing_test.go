@@ -0,0 +1,99 @@
+package vorl
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func alt(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}, Alt: true}
+}
+
+func TestWordMotions(t *testing.T) {
+	tests := []struct {
+		value    string
+		pos      int
+		backward int
+		forward  int
+	}{
+		{"", 0, 0, 0},
+		{"git commit", 10, 4, 10},
+		{"git commit", 4, 0, 10},
+		{"git commit", 3, 0, 10},
+		{"git commit", 5, 4, 10},
+		{"git  commit  ", 13, 5, 13},
+		{"  git", 0, 0, 5},
+		{"a b c", 2, 0, 3},
+	}
+
+	for _, tt := range tests {
+		value := []rune(tt.value)
+		if got := wordStartBackward(value, tt.pos); got != tt.backward {
+			t.Errorf("wordStartBackward(%q, %d) = %d, want %d", tt.value, tt.pos, got, tt.backward)
+		}
+		if got := wordEndForward(value, tt.pos); got != tt.forward {
+			t.Errorf("wordEndForward(%q, %d) = %d, want %d", tt.value, tt.pos, got, tt.forward)
+		}
+	}
+}
+
+func TestEditingKeys(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		pos   int
+		keys  []tea.KeyMsg
+		want  string
+		cur   int
+	}{
+		{"kill word backward", "git commit -m", 13, []tea.KeyMsg{{Type: tea.KeyCtrlW}}, "git commit ", 11},
+		{"kill word forward", "git commit -m", 3, []tea.KeyMsg{alt('d')}, "git -m", 3},
+		{"kill to end", "git commit", 4, []tea.KeyMsg{{Type: tea.KeyCtrlK}}, "git ", 4},
+		{"kill to start", "git commit", 4, []tea.KeyMsg{{Type: tea.KeyCtrlU}}, "commit", 0},
+		{"kills are merged", "a b c", 5, []tea.KeyMsg{{Type: tea.KeyCtrlW}, {Type: tea.KeyCtrlW}, {Type: tea.KeyCtrlY}}, "a b c", 5},
+		{"yank pop", "one two", 7, []tea.KeyMsg{{Type: tea.KeyCtrlW}, {Type: tea.KeyLeft}, {Type: tea.KeyCtrlU}, {Type: tea.KeyCtrlY}, alt('y')}, "two ", 3},
+		{"transpose", "gti", 2, []tea.KeyMsg{{Type: tea.KeyCtrlT}}, "git", 3},
+		{"transpose at the end", "gi", 2, []tea.KeyMsg{{Type: tea.KeyCtrlT}}, "ig", 2},
+		{"upper case word", "git commit", 0, []tea.KeyMsg{alt('u')}, "GIT commit", 3},
+		{"lower case word", "GIT COMMIT", 3, []tea.KeyMsg{alt('l')}, "GIT commit", 10},
+		{"capitalize word", "git commit", 3, []tea.KeyMsg{alt('c')}, "git Commit", 10},
+		{"undo", "git commit", 10, []tea.KeyMsg{{Type: tea.KeyCtrlW}, {Type: tea.KeyCtrlUnderscore}}, "git commit", 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := newTestInput()
+			ri.textInput.SetValue(tt.value)
+			ri.textInput.SetCursor(tt.pos)
+
+			ri = pressKeys(ri, tt.keys...)
+
+			if got := ri.Value(); got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+			if got := ri.textInput.Position(); got != tt.cur {
+				t.Errorf("cursor = %d, want %d", got, tt.cur)
+			}
+		})
+	}
+}
+
+func TestEditorFinishedKeepsSpaces(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"echo 'a  b'\n", "echo 'a  b'"},
+		{"git commit\n-m x\n", "git commit -m x"},
+		{"ls\r\n", "ls"},
+		{"  ls", "  ls"},
+	}
+
+	for _, tt := range tests {
+		ri, _ := newTestInput().editorFinishedUpdate(editorFinished{content: tt.content})
+		if got := ri.textInput.Value(); got != tt.want {
+			t.Errorf("editing %q left %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package vorl
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const defaultEditor = "vi"
+
+// editorFinished is sent when the external editor opened to edit the input
+// exits.
+type editorFinished struct {
+	content string
+	err     error
+}
+
+// editorUpdate opens the input in an external editor when the editor key
+// binding is pressed. If the binding has a prefix, as ctrl+x ctrl+e, the
+// prefix is remembered until the next key press. It returns false if the key
+// was not handled.
+func (ri replInput) editorUpdate(msg tea.KeyMsg) (replInput, tea.Cmd, bool) {
+	prefixEnabled := ri.keyMap.EditorPrefix.Enabled()
+
+	switch {
+	case ri.editorPrefixPressed:
+		ri.editorPrefixPressed = false
+		if key.Matches(msg, ri.keyMap.EditInEditor) {
+			return ri, ri.openEditor(), true
+		}
+
+	case prefixEnabled && key.Matches(msg, ri.keyMap.EditorPrefix):
+		ri.editorPrefixPressed = true
+		return ri, nil, true
+
+	case !prefixEnabled && key.Matches(msg, ri.keyMap.EditInEditor):
+		return ri, ri.openEditor(), true
+	}
+
+	return ri, nil, false
+}
+
+// openEditor writes the input to a temporary file and opens it with
+// $VISUAL, $EDITOR or vi, suspending the TUI until the editor exits.
+func (ri replInput) openEditor() tea.Cmd {
+	f, err := os.CreateTemp("", "vorl-*.txt")
+	if err != nil {
+		return func() tea.Msg {
+			return editorFinished{err: err}
+		}
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(ri.textInput.Value()); err != nil {
+		os.Remove(f.Name())
+		return func() tea.Msg {
+			return editorFinished{err: err}
+		}
+	}
+
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+
+	// the editor may include arguments, as in 'code --wait'
+	args := append(strings.Fields(editor), f.Name())
+	cmd := exec.Command(args[0], args[1:]...)
+
+	path := f.Name()
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+
+		if err != nil {
+			return editorFinished{err: err}
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return editorFinished{err: err}
+		}
+
+		return editorFinished{content: string(content)}
+	})
+}
+
+// editorFinishedUpdate loads the edited content in the prompt, or runs it
+// if the REPL was configured to do so.
+func (ri replInput) editorFinishedUpdate(msg editorFinished) (replInput, tea.Cmd) {
+	if msg.err != nil {
+		return ri, tea.Println(fmt.Sprintf("ERROR: %v", msg.err))
+	}
+
+	// the prompt has a single line, and the spaces typed are kept since
+	// they may be quoted
+	content := strings.TrimSuffix(msg.content, "\n")
+	content = strings.TrimSuffix(content, "\r")
+	content = strings.ReplaceAll(content, "\r\n", " ")
+	content = strings.ReplaceAll(content, "\n", " ")
+
+	if ri.executeAfterEdit && content != "" {
+		var valid bool
+		ri, valid = ri.validateCommand(content)
+		if !valid {
+			return ri, nil
+		}
+
+		ri.textInput.SetValue("")
+		ri.historyIndex = 0
+		ri.editing.undoStack = nil
+		return ri.Exec(content)
+	}
+
+	ri.pushUndo([]rune(ri.textInput.Value()), ri.textInput.Position())
+	ri.textInput.SetValue(content)
+	ri.textInput.CursorEnd()
+
+	return ri, nil
+}
@@ -0,0 +1,70 @@
+package vorl
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Hint describes the command being typed. It is shown dimmed under the
+// input.
+type Hint struct {
+	// Signature is the expected usage of the command, e.g.
+	// 'fetch <resource> [--limit N]'.
+	Signature string
+
+	// Description explains the argument under the cursor.
+	Description string
+}
+
+// ArgumentHinter can be implemented by an Interpreter to show hints while
+// the user types. cursor is the byte offset of the cursor in input.
+type ArgumentHinter interface {
+	Hint(input string, cursor int) Hint
+}
+
+var hintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
+
+// updateHint asks the interpreter for a new hint if the input or the cursor
+// moved since the last one.
+func (ri replInput) updateHint() replInput {
+	if ri.hintFn == nil {
+		return ri
+	}
+
+	value := ri.textInput.Value()
+	pos := ri.textInput.Position()
+	if value == ri.hintInput && pos == ri.hintPos {
+		return ri
+	}
+	ri.hintInput = value
+	ri.hintPos = pos
+
+	if value == "" {
+		ri.hint = Hint{}
+		return ri
+	}
+
+	cursor := len(string([]rune(value)[:min(pos, len([]rune(value)))]))
+	ri.hint = ri.hintFn(value, cursor)
+
+	return ri
+}
+
+// hintView returns the lines shown under the input, or an empty string if
+// there is no hint.
+func (ri replInput) hintView() string {
+	lines := []string{}
+	if ri.hint.Signature != "" {
+		lines = append(lines, hintStyle.Render(ri.hint.Signature))
+	}
+	if ri.hint.Description != "" {
+		lines = append(lines, hintStyle.Render(ri.hint.Description))
+	}
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "\n" + strings.Join(lines, "\n")
+}
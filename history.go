@@ -0,0 +1,124 @@
+package vorl
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+const historyCommandName = "history"
+
+type historyEntryStatus int
+
+const (
+	historyEntryStatusUnknown historyEntryStatus = iota
+	historyEntryStatusRunning
+	historyEntryStatusOK
+	historyEntryStatusFailed
+)
+
+func (s historyEntryStatus) String() string {
+	switch s {
+	case historyEntryStatusRunning:
+		return "running"
+	case historyEntryStatusOK:
+		return "ok"
+	case historyEntryStatusFailed:
+		return "failed"
+	default:
+		return "-"
+	}
+}
+
+type historyEntry struct {
+	// command is the command as stored in the history, after redaction
+	command string
+	time    time.Time
+	status  historyEntryStatus
+
+	// run is the command run when the entry is selected. Redacted entries
+	// keep the command as typed for the rest of the session, and entries
+	// loaded from the store that may be redacted cannot be run again.
+	run string
+}
+
+// historyCommand is sent when the user runs the history built-in. It is
+// handled by the model because the entries live there.
+type historyCommand []string
+
+// execCommand asks the REPL to run a command as if it had been typed in the
+// prompt.
+type execCommand string
+
+// parseHistoryCommand returns the arguments of the history built-in, and
+// false if the input is not a call to it.
+func parseHistoryCommand(input string) ([]string, bool) {
+	tokens := Tokenize(input, -1)
+	if len(tokens.Tokens) == 0 || tokens.Tokens[0].Value != historyCommandName {
+		return nil, false
+	}
+
+	return tokens.Values()[1:], true
+}
+
+// historyResult builds the table shown by the history built-in. Arguments
+// are substrings that the command must contain, except for --failed which
+// only keeps the commands that returned an error.
+func historyResult(entries []historyEntry, args []string) interface{} {
+	onlyFailed := false
+	terms := []string{}
+	for _, arg := range args {
+		if arg == "--failed" {
+			onlyFailed = true
+			continue
+		}
+		terms = append(terms, arg)
+	}
+
+	rows := [][]string{{"#", "time", "command", "status"}}
+
+entries:
+	for i, entry := range entries {
+		if onlyFailed && entry.status != historyEntryStatusFailed {
+			continue
+		}
+
+		for _, term := range terms {
+			if !strings.Contains(entry.command, term) {
+				continue entries
+			}
+		}
+
+		t := "-"
+		if !entry.time.IsZero() {
+			t = entry.time.Format(time.DateTime)
+		}
+
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			t,
+			entry.command,
+			entry.status.String(),
+		})
+	}
+
+	if len(rows) == 1 {
+		return CommandResultSimple("no matching history entries")
+	}
+
+	return CommandResultTable{
+		Table: rows,
+		OnSelect: func(selected []string) interface{} {
+			i, err := strconv.Atoi(selected[0])
+			if err != nil || i < 1 || i > len(entries) {
+				return nil
+			}
+
+			if entries[i-1].run == "" {
+				return CommandResultSimple("the command was redacted and cannot be run again")
+			}
+
+			return execCommand(entries[i-1].run)
+		},
+	}
+}
@@ -0,0 +1,73 @@
+package vorl
+
+import (
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestInput() replInput {
+	return newInput(
+		">",
+		nil,
+		func(string) []string { return nil },
+		func(input string) (string, bool) { return input, true },
+		func(string) []string { return nil },
+		nil,
+		DefaultKeyMap(),
+	)
+}
+
+func pressKeys(ri replInput, keys ...tea.KeyMsg) replInput {
+	for _, k := range keys {
+		ri, _ = ri.Update(k)
+	}
+	return ri
+}
+
+func runes(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func TestHistoryStartingWith(t *testing.T) {
+	ri := newTestInput()
+	ri.history = []string{"ls", "git status", "ls -l", "git log", "ls -l", "ls"}
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"ls", "ls -l", "git log", "git status"}},
+		{"git", []string{"git log", "git status"}},
+		{"ls", []string{"ls -l"}},
+		{"ls -l", []string{}},
+		{"cd", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := ri.historyStartingWith(tt.prefix); !slices.Equal(got, tt.want) {
+			t.Errorf("historyStartingWith(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryNavigationKeepsPrefix(t *testing.T) {
+	ri := newTestInput()
+	ri.history = []string{"git status", "ls", "git log"}
+
+	ri = pressKeys(ri, runes("git"), tea.KeyMsg{Type: tea.KeyUp})
+	if got := ri.Value(); got != "git log" {
+		t.Fatalf("value = %q after one up, want %q", got, "git log")
+	}
+
+	ri = pressKeys(ri, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp})
+	if got := ri.Value(); got != "git status" {
+		t.Fatalf("value = %q past the oldest match, want %q", got, "git status")
+	}
+
+	ri = pressKeys(ri, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown})
+	if got := ri.Value(); got != "git" {
+		t.Errorf("value = %q past the newest match, want the prefix", got)
+	}
+}
@@ -0,0 +1,217 @@
+package vorl
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// HistoryStore persists the commands executed in the REPL.
+type HistoryStore interface {
+	// Load returns the stored commands, oldest first.
+	Load() ([]string, error)
+
+	// Append stores a command as the newest entry.
+	Append(command string) error
+
+	// Search returns the stored commands containing query, newest first.
+	Search(query string) ([]string, error)
+
+	// Compact removes repeated commands, keeping only their newest
+	// occurrence.
+	Compact() error
+}
+
+// FileHistoryStore stores the history in a file, one command per line. The
+// file is read once and kept in memory, so searches do not read it on every
+// key press. Commands written to the file by other processes are seen after
+// the next Load.
+type FileHistoryStore struct {
+	path string
+	mu   sync.Mutex
+
+	// commands is the content of the file, nil until it is read
+	commands []string
+}
+
+func NewFileHistoryStore(path string) *FileHistoryStore {
+	return &FileHistoryStore{
+		path: path,
+	}
+}
+
+func (s *FileHistoryStore) Load() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.commands = commands
+
+	return slices.Clone(commands), nil
+}
+
+// cached returns the commands of the file, reading it if it was not read
+// yet.
+func (s *FileHistoryStore) cached() ([]string, error) {
+	if s.commands != nil {
+		return s.commands, nil
+	}
+
+	commands, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.commands = commands
+
+	return commands, nil
+}
+
+func (s *FileHistoryStore) load() ([]string, error) {
+	hb, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	commands := []string{}
+	for _, c := range strings.Split(string(hb), "\n") {
+		command := strings.TrimSpace(c)
+		if command == "" {
+			continue
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+func (s *FileHistoryStore) Append(command string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hf, err := os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer hf.Close()
+
+	if _, err := hf.WriteString(command + "\n"); err != nil {
+		return err
+	}
+
+	if s.commands != nil {
+		s.commands = append(s.commands, command)
+	}
+
+	return nil
+}
+
+func (s *FileHistoryStore) Search(query string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands, err := s.cached()
+	if err != nil {
+		return nil, err
+	}
+
+	return searchCommands(commands, query), nil
+}
+
+func (s *FileHistoryStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	commands, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	compacted := compactCommands(commands)
+	content := strings.Join(compacted, "\n")
+	if content != "" {
+		content += "\n"
+	}
+
+	if err := os.WriteFile(s.path, []byte(content), 0600); err != nil {
+		return err
+	}
+	s.commands = compacted
+
+	return nil
+}
+
+// MemoryHistoryStore keeps the history in memory only. It is used when no
+// history file is given.
+type MemoryHistoryStore struct {
+	commands []string
+	mu       sync.Mutex
+}
+
+func NewMemoryHistoryStore(commands ...string) *MemoryHistoryStore {
+	return &MemoryHistoryStore{
+		commands: commands,
+	}
+}
+
+func (s *MemoryHistoryStore) Load() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.commands), nil
+}
+
+func (s *MemoryHistoryStore) Append(command string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, command)
+	return nil
+}
+
+func (s *MemoryHistoryStore) Search(query string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return searchCommands(s.commands, query), nil
+}
+
+func (s *MemoryHistoryStore) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = compactCommands(s.commands)
+	return nil
+}
+
+func searchCommands(commands []string, query string) []string {
+	results := []string{}
+	for i := len(commands) - 1; i >= 0; i-- {
+		if strings.Contains(commands[i], query) {
+			results = append(results, commands[i])
+		}
+	}
+
+	return results
+}
+
+func compactCommands(commands []string) []string {
+	compacted := []string{}
+	seen := map[string]bool{}
+	for i := len(commands) - 1; i >= 0; i-- {
+		if seen[commands[i]] {
+			continue
+		}
+		seen[commands[i]] = true
+		compacted = append(compacted, commands[i])
+	}
+	slices.Reverse(compacted)
+
+	return compacted
+}
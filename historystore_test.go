@@ -0,0 +1,89 @@
+package vorl
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestFileHistoryStoreSearchesInMemory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history")
+	if err := os.WriteFile(path, []byte("ls\ncd /tmp\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewFileHistoryStore(path)
+	if _, err := s.Search("ls"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the file is not read again by searches
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append("ls -l"); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := s.Search("ls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ls -l", "ls"}; !slices.Equal(got, want) {
+		t.Errorf("Search = %q, want %q", got, want)
+	}
+
+	if err := s.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ls -l"}; !slices.Equal(got, want) {
+		t.Errorf("Load after Compact = %q, want %q", got, want)
+	}
+}
+
+func TestSearchCommands(t *testing.T) {
+	commands := []string{"ls", "cd /tmp", "ls -l", "git status", "ls"}
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"ls", []string{"ls", "ls -l", "ls"}},
+		{"tmp", []string{"cd /tmp"}},
+		{"LS", []string{}},
+		{"", []string{"ls", "git status", "ls -l", "cd /tmp", "ls"}},
+		{"missing", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := searchCommands(commands, tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("searchCommands(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestCompactCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		commands []string
+		want     []string
+	}{
+		{"empty", []string{}, []string{}},
+		{"no repeats", []string{"a", "b"}, []string{"a", "b"}},
+		{"keeps the newest", []string{"a", "b", "a", "c"}, []string{"b", "a", "c"}},
+		{"consecutive", []string{"a", "a", "a"}, []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compactCommands(tt.commands); !slices.Equal(got, tt.want) {
+				t.Errorf("compactCommands(%q) = %q, want %q", tt.commands, got, tt.want)
+			}
+		})
+	}
+}
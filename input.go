@@ -1,9 +1,9 @@
 package vorl
 
 import (
-	"slices"
 	"strings"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 )
@@ -20,8 +20,34 @@ type replInput struct {
 	textInput       textinput.Model
 	execFn          func(string) tea.Cmd
 	suggestFn       func(string) []string
+	redactFn        func(string) (string, bool)
+	searchFn        func(string) []string
 	history         []string
 	executedCommand bool
+	keyMap          KeyMap
+
+	// historyPrefix is the input typed before navigating the history, and
+	// historyMatches the entries starting with it, newest first
+	historyPrefix  string
+	historyMatches []string
+
+	editing editingState
+
+	vi viState
+
+	editorPrefixPressed bool
+	executeAfterEdit    bool
+
+	continuationPrompt string
+
+	hintFn    func(string, int) Hint
+	hint      Hint
+	hintInput string
+	hintPos   int
+
+	validateFn    func(string) error
+	validationErr error
+	invalidInput  string
 
 	state                replInputState
 	reverseSearchInput   string
@@ -33,20 +59,47 @@ func newInput(
 	prompt string,
 	execFn func(string) tea.Cmd,
 	suggestFn func(string) []string,
+	redactFn func(string) (string, bool),
+	searchFn func(string) []string,
 	initialHistory []string,
+	keyMap KeyMap,
 ) replInput {
 
 	textInput := textinput.New()
-	textInput.Prompt = prompt + " "
 	textInput.ShowSuggestions = true
 	textInput.Focus()
 
-	return replInput{
+	// deletions are done by replInput to save the text in the kill ring
+	textInput.KeyMap.WordBackward = keyMap.WordBackward
+	textInput.KeyMap.WordForward = keyMap.WordForward
+	textInput.KeyMap.DeleteWordBackward.SetEnabled(false)
+	textInput.KeyMap.DeleteWordForward.SetEnabled(false)
+	textInput.KeyMap.DeleteAfterCursor.SetEnabled(false)
+	textInput.KeyMap.DeleteBeforeCursor.SetEnabled(false)
+
+	ri := replInput{
 		textInput: textInput,
 		execFn:    execFn,
 		suggestFn: suggestFn,
+		redactFn:  redactFn,
+		searchFn:  searchFn,
 		history:   initialHistory,
+		keyMap:    keyMap,
 	}
+	ri.SetPrompt(prompt, "")
+
+	return ri
+}
+
+// SetPrompt changes the prompt shown before the input. The continuation
+// prompt defaults to the main one.
+func (ri *replInput) SetPrompt(prompt string, continuation string) {
+	if continuation == "" {
+		continuation = prompt
+	}
+
+	ri.textInput.Prompt = prompt + " "
+	ri.continuationPrompt = continuation + " "
 }
 
 func (ri replInput) Update(msg tea.Msg) (replInput, tea.Cmd) {
@@ -55,78 +108,118 @@ func (ri replInput) Update(msg tea.Msg) (replInput, tea.Cmd) {
 
 	switch ri.state {
 	case replInputStateReadingInput:
+		if msg, ok := msg.(tea.KeyMsg); ok && ri.vi.enabled {
+			var cmd tea.Cmd
+			var handled bool
+			ri, cmd, handled = ri.viUpdate(msg)
+			cmds = append(cmds, cmd)
+			if handled {
+				break
+			}
+		}
+
 		var cmd tea.Cmd
 		ri, cmd = ri.readingInputUpdate(msg)
 		cmds = append(cmds, cmd)
 
+		// keys passed through to the text input, e.g. End, can leave the
+		// cursor after the end of the input in normal mode
+		if ri.vi.enabled {
+			ri.viClampCursor()
+		}
+
 	case replInputStateReverseSearch:
 		var cmd tea.Cmd
 		ri, cmd = ri.reverseSearchUpdate(msg)
 		cmds = append(cmds, cmd)
 	}
 
+	ri = ri.updateHint()
+
 	return ri, tea.Batch(cmds...)
 }
+
 func (ri replInput) readingInputUpdate(msg tea.Msg) (replInput, tea.Cmd) {
 	var cmds []tea.Cmd
 
+	if msg, ok := msg.(editorFinished); ok {
+		return ri.editorFinishedUpdate(msg)
+	}
+
 	input := ri.textInput.Value()
+	edited := false
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		switch msg.Type {
-		case tea.KeyEnter:
+		var cmd tea.Cmd
+		var handled bool
+		ri, cmd, handled = ri.editorUpdate(msg)
+		if handled {
+			return ri, cmd
+		}
+
+		switch {
+		case key.Matches(msg, ri.keyMap.Execute):
+			if input != "" {
+				var valid bool
+				ri, valid = ri.validate(input)
+				if !valid {
+					return ri, nil
+				}
+			}
+
 			ri.textInput.SetValue("")
 			ri.historyIndex = 0
+			ri.editing.undoStack = nil
+			ri.editing.lastAction = editActionNone
 
 			if input != "" {
-				cmds = append(cmds, tea.Printf("%s%s", ri.textInput.Prompt, input))
-
-				if ri.execFn != nil {
-					cmds = append(
-						cmds,
-						ri.execFn(input),
-					)
-					ri.executedCommand = true
-					ri.history = append(ri.history, input)
-				}
+				var cmd tea.Cmd
+				ri, cmd = ri.Exec(input)
+				cmds = append(cmds, cmd)
 			}
 
-		case tea.KeyCtrlC:
+		case key.Matches(msg, ri.keyMap.ClearInput):
 			ri.textInput.SetValue("")
 			ri.historyIndex = 0
+			ri.editing.undoStack = nil
+			ri.editing.lastAction = editActionNone
 
-		case tea.KeyUp:
-			ri.historyIndex = min(ri.historyIndex+1, len(ri.history))
+		case key.Matches(msg, ri.keyMap.HistoryPrevious):
+			ri = ri.historyPrevious()
 
-		case tea.KeyDown:
-			ri.historyIndex = max(ri.historyIndex-1, 0)
+		case key.Matches(msg, ri.keyMap.HistoryNext):
+			ri = ri.historyNext()
 
-		case tea.KeyCtrlR:
+		case key.Matches(msg, ri.keyMap.ReverseSearch):
 			ri.state = replInputStateReverseSearch
 
 		default:
 			ri.historyIndex = 0
+			ri, edited = ri.editingUpdate(msg)
 		}
 	}
 
-	if ri.historyIndex != 0 {
-		ri.textInput.SetValue(ri.history[len(ri.history)-ri.historyIndex])
-	}
-
 	suggestions := ri.suggestFn(input)
 	suggestions = append(ri.history, suggestions...)
 	ri.textInput.SetSuggestions(suggestions)
 
-	var cmd tea.Cmd
-	ri.textInput, cmd = ri.textInput.Update(msg)
-	cmds = append(cmds, cmd)
+	if !edited {
+		prevValue := []rune(ri.textInput.Value())
+		prevPos := ri.textInput.Position()
+
+		var cmd tea.Cmd
+		ri.textInput, cmd = ri.textInput.Update(msg)
+		cmds = append(cmds, cmd)
+
+		ri = ri.trackEdit(prevValue, prevPos, msg)
+	}
 
 	return ri, tea.Batch(cmds...)
 }
 
 func (ri replInput) reverseSearchUpdate(msg tea.Msg) (replInput, tea.Cmd) {
 	if msg, ok := msg.(tea.KeyMsg); ok {
-		switch msg.Type {
-		case tea.KeyEnter:
+		switch {
+		case key.Matches(msg, ri.keyMap.Execute):
 			newInput := ""
 			if len(ri.reverseSearchResults) > 0 {
 				newInput = ri.reverseSearchResults[ri.reverseSearchIndex]
@@ -138,33 +231,29 @@ func (ri replInput) reverseSearchUpdate(msg tea.Msg) (replInput, tea.Cmd) {
 			ri.state = replInputStateReadingInput
 			ri.reverseSearchResults = []string{}
 
-		case tea.KeyCtrlC:
+		case key.Matches(msg, ri.keyMap.ClearInput):
 			ri.reverseSearchInput = ""
 			ri.state = replInputStateReadingInput
 			ri.reverseSearchResults = []string{}
 
-		case tea.KeyCtrlR:
+		case key.Matches(msg, ri.keyMap.ReverseSearch):
 			ri.reverseSearchIndex = min(ri.reverseSearchIndex+1, len(ri.reverseSearchResults)-1)
 
-		case tea.KeyBackspace:
+		case msg.Type == tea.KeyBackspace:
 			if len(ri.reverseSearchInput) == 0 {
 				break
 			}
 
 			ri.reverseSearchInput = ri.reverseSearchInput[:len(ri.reverseSearchInput)-1]
 
+			ri.reverseSearchIndex = 0
+
 			if len(ri.reverseSearchInput) == 0 {
 				ri.reverseSearchResults = []string{}
 				break
 			}
 
-			for _, histInput := range ri.history {
-				if strings.Contains(histInput, ri.reverseSearchInput) {
-					ri.reverseSearchResults = append(ri.reverseSearchResults, histInput)
-				}
-			}
-
-			slices.Reverse(ri.reverseSearchResults)
+			ri.reverseSearchResults = ri.searchFn(ri.reverseSearchInput)
 
 		default:
 			if len(msg.String()) > 1 {
@@ -173,19 +262,97 @@ func (ri replInput) reverseSearchUpdate(msg tea.Msg) (replInput, tea.Cmd) {
 
 			ri.reverseSearchIndex = 0
 			ri.reverseSearchInput += msg.String()
-			ri.reverseSearchResults = []string{}
+			ri.reverseSearchResults = ri.searchFn(ri.reverseSearchInput)
+		}
+	}
 
-			for _, histInput := range ri.history {
-				if strings.Contains(histInput, ri.reverseSearchInput) {
-					ri.reverseSearchResults = append(ri.reverseSearchResults, histInput)
-				}
-			}
+	return ri, nil
+}
 
-			slices.Reverse(ri.reverseSearchResults)
+// historyPrevious replaces the input with the previous history entry
+// starting with what was typed before navigating the history.
+func (ri replInput) historyPrevious() replInput {
+	if ri.historyIndex == 0 {
+		ri.historyPrefix = ri.textInput.Value()
+		ri.historyMatches = ri.historyStartingWith(ri.historyPrefix)
+	}
+	ri.historyIndex = min(ri.historyIndex+1, len(ri.historyMatches))
+
+	if ri.historyIndex != 0 {
+		ri.textInput.SetValue(ri.historyMatches[ri.historyIndex-1])
+		ri.textInput.CursorEnd()
+	}
+
+	return ri
+}
+
+// historyNext replaces the input with the next history entry, or with what
+// was typed before navigating the history when going past the newest one.
+func (ri replInput) historyNext() replInput {
+	switch ri.historyIndex {
+	case 0:
+		return ri
+
+	case 1:
+		ri.textInput.SetValue(ri.historyPrefix)
+
+	default:
+		ri.textInput.SetValue(ri.historyMatches[ri.historyIndex-2])
+	}
+	ri.historyIndex--
+	ri.textInput.CursorEnd()
+
+	return ri
+}
+
+// historyStartingWith returns the distinct history entries that start with
+// prefix, newest first. Entries equal to the prefix are skipped since
+// selecting them would not change the input.
+func (ri replInput) historyStartingWith(prefix string) []string {
+	matches := []string{}
+	seen := map[string]bool{}
+
+	for i := len(ri.history) - 1; i >= 0; i-- {
+		entry := ri.history[i]
+		if seen[entry] || !strings.HasPrefix(entry, prefix) {
+			continue
 		}
+		if prefix != "" && entry == prefix {
+			continue
+		}
+
+		seen[entry] = true
+		matches = append(matches, entry)
 	}
 
-	return ri, nil
+	return matches
+}
+
+// Exec echoes the input after the prompt and runs it as if it had been typed
+// by the user.
+func (ri replInput) Exec(input string) (replInput, tea.Cmd) {
+	return ri.exec(input, ri.textInput.Prompt)
+}
+
+// ExecContinued is like Exec, but echoes the input after the continuation
+// prompt.
+func (ri replInput) ExecContinued(input string) (replInput, tea.Cmd) {
+	return ri.exec(input, ri.continuationPrompt)
+}
+
+func (ri replInput) exec(input string, prompt string) (replInput, tea.Cmd) {
+	cmds := []tea.Cmd{tea.Printf("%s%s", prompt, input)}
+
+	if ri.execFn != nil {
+		cmds = append(cmds, ri.execFn(input))
+		ri.executedCommand = true
+
+		if command, ok := ri.redactFn(input); ok {
+			ri.history = append(ri.history, command)
+		}
+	}
+
+	return ri, tea.Batch(cmds...)
 }
 
 func (ri replInput) ExecutedCommand() bool {
@@ -206,7 +373,10 @@ func (ri replInput) View() string {
 		return "rs: '" + ri.reverseSearchInput + "' " + ri.textInput.Prompt + " " + searchResult
 
 	case replInputStateReadingInput:
-		return ri.textInput.View()
+		if ri.vi.enabled {
+			return ri.viView() + ri.validationErrorView() + ri.hintView()
+		}
+		return ri.textInput.View() + ri.validationErrorView() + ri.hintView()
 
 	default:
 		return ri.textInput.View()
@@ -0,0 +1,442 @@
+package vorl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// KeyMap defines the key bindings of every action of the REPL.
+type KeyMap struct {
+	// Quit exits the REPL.
+	Quit key.Binding
+
+	// EnterInteraction moves the focus from the prompt to the list or table
+	// shown as the result of the last command.
+	EnterInteraction key.Binding
+
+	// LeaveInteraction moves the focus back to the prompt.
+	LeaveInteraction key.Binding
+
+	// QuitInteraction moves the focus back to the prompt, unless a list
+	// filter is being typed.
+	QuitInteraction key.Binding
+
+	// Select runs the action of the selected list item or table row.
+	Select key.Binding
+
+	// Back shows the result that the one in focus was drilled down from.
+	Back key.Binding
+
+	// LineUp, LineDown, PageUp, PageDown, GotoTop and GotoBottom move the
+	// cursor of interactive lists, tables and trees. HalfPageUp and
+	// HalfPageDown are only used by tables.
+	LineUp       key.Binding
+	LineDown     key.Binding
+	PageUp       key.Binding
+	PageDown     key.Binding
+	HalfPageUp   key.Binding
+	HalfPageDown key.Binding
+	GotoTop      key.Binding
+	GotoBottom   key.Binding
+
+	// ToggleSelection and SelectAll mark list items when the result
+	// accepts several of them.
+	ToggleSelection key.Binding
+	SelectAll       key.Binding
+
+	// ToggleGroup collapses or expands the group of list items under the
+	// cursor.
+	ToggleGroup key.Binding
+
+	// SortByColumn sorts a table by a column, the first key by the first
+	// column and so on, or reverses the order if it is already sorted by it.
+	// The previous sort columns break ties. ClearSort restores the original
+	// order.
+	SortByColumn key.Binding
+	ClearSort    key.Binding
+
+	// Expand and Collapse show and hide the children of the tree node under
+	// the cursor.
+	Expand   key.Binding
+	Collapse key.Binding
+
+	// Filter starts typing a query that hides the table rows or tree nodes
+	// that do not match it. Search highlights the matching table rows
+	// instead, and NextMatch and PreviousMatch move the cursor between them.
+	// AcceptFilter stops typing the query, and ClearFilter removes it.
+	Filter        key.Binding
+	Search        key.Binding
+	NextMatch     key.Binding
+	PreviousMatch key.Binding
+	AcceptFilter  key.Binding
+	ClearFilter   key.Binding
+
+	// Confirm runs an action that asks for confirmation. Any other key
+	// cancels it.
+	Confirm key.Binding
+
+	// CopyRows copies the rows of a table to the clipboard, and SaveRows
+	// asks for a file to save them to, as they are sorted and filtered.
+	CopyRows key.Binding
+	SaveRows key.Binding
+
+	Execute         key.Binding
+	ClearInput      key.Binding
+	HistoryPrevious key.Binding
+	HistoryNext     key.Binding
+	ReverseSearch   key.Binding
+
+	WordBackward     key.Binding
+	WordForward      key.Binding
+	KillWordBackward key.Binding
+	KillWordForward  key.Binding
+	KillToEnd        key.Binding
+	KillToStart      key.Binding
+	Yank             key.Binding
+	YankPop          key.Binding
+	Transpose        key.Binding
+	UpperCaseWord    key.Binding
+	LowerCaseWord    key.Binding
+	CapitalizeWord   key.Binding
+	Undo             key.Binding
+
+	// EditInEditor opens the input in $EDITOR. If EditorPrefix is enabled,
+	// it has to be pressed right after it.
+	EditorPrefix key.Binding
+	EditInEditor key.Binding
+}
+
+// DefaultKeyMap returns the key bindings used when no KeyMap is given to
+// NewREPL.
+func DefaultKeyMap() KeyMap {
+	return KeyMap{
+		Quit: key.NewBinding(
+			key.WithKeys("ctrl+d"),
+			key.WithHelp("ctrl+d", "quit"),
+		),
+		EnterInteraction: key.NewBinding(
+			key.WithKeys("ctrl+up", "alt+k"),
+			key.WithHelp("ctrl+↑/alt+k", "interact with result"),
+		),
+		LeaveInteraction: key.NewBinding(
+			key.WithKeys("ctrl+down", "ctrl+j", "ctrl+c"),
+			key.WithHelp("ctrl+↓/ctrl+j", "back to prompt"),
+		),
+		QuitInteraction: key.NewBinding(
+			key.WithKeys("q"),
+			key.WithHelp("q", "back to prompt"),
+		),
+		Select: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "select"),
+		),
+		Back: key.NewBinding(
+			key.WithKeys("backspace", "esc"),
+			key.WithHelp("esc", "back"),
+		),
+		LineUp: key.NewBinding(
+			key.WithKeys("up", "k"),
+			key.WithHelp("↑/k", "up"),
+		),
+		LineDown: key.NewBinding(
+			key.WithKeys("down", "j"),
+			key.WithHelp("↓/j", "down"),
+		),
+		PageUp: key.NewBinding(
+			key.WithKeys("pgup", "b"),
+			key.WithHelp("pgup/b", "page up"),
+		),
+		PageDown: key.NewBinding(
+			key.WithKeys("pgdown", "f"),
+			key.WithHelp("pgdn/f", "page down"),
+		),
+		HalfPageUp: key.NewBinding(
+			key.WithKeys("u", "ctrl+u"),
+			key.WithHelp("u", "½ page up"),
+		),
+		HalfPageDown: key.NewBinding(
+			key.WithKeys("d", "ctrl+d"),
+			key.WithHelp("d", "½ page down"),
+		),
+		GotoTop: key.NewBinding(
+			key.WithKeys("home", "g"),
+			key.WithHelp("g/home", "go to start"),
+		),
+		GotoBottom: key.NewBinding(
+			key.WithKeys("end", "G"),
+			key.WithHelp("G/end", "go to end"),
+		),
+		ToggleSelection: key.NewBinding(
+			key.WithKeys(" "),
+			key.WithHelp("space", "toggle"),
+		),
+		SelectAll: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "select all"),
+		),
+		ToggleGroup: key.NewBinding(
+			key.WithKeys("tab"),
+			key.WithHelp("tab", "collapse/expand group"),
+		),
+		SortByColumn: key.NewBinding(
+			key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+			key.WithHelp("1-9", "sort by column"),
+		),
+		ClearSort: key.NewBinding(
+			key.WithKeys("0"),
+			key.WithHelp("0", "clear sort"),
+		),
+		Expand: key.NewBinding(
+			key.WithKeys("right", "l"),
+			key.WithHelp("→/l", "expand"),
+		),
+		Collapse: key.NewBinding(
+			key.WithKeys("left", "h"),
+			key.WithHelp("←/h", "collapse"),
+		),
+		Filter: key.NewBinding(
+			key.WithKeys("/"),
+			key.WithHelp("/", "filter"),
+		),
+		Search: key.NewBinding(
+			key.WithKeys("?"),
+			key.WithHelp("?", "search"),
+		),
+		NextMatch: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "next match"),
+		),
+		PreviousMatch: key.NewBinding(
+			key.WithKeys("N"),
+			key.WithHelp("N", "previous match"),
+		),
+		AcceptFilter: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "apply filter"),
+		),
+		ClearFilter: key.NewBinding(
+			key.WithKeys("esc"),
+			key.WithHelp("esc", "clear filter"),
+		),
+		Confirm: key.NewBinding(
+			key.WithKeys("y", "Y"),
+			key.WithHelp("y", "confirm"),
+		),
+		CopyRows: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "copy"),
+		),
+		SaveRows: key.NewBinding(
+			key.WithKeys("ctrl+s"),
+			key.WithHelp("ctrl+s", "save"),
+		),
+
+		Execute: key.NewBinding(
+			key.WithKeys("enter"),
+			key.WithHelp("enter", "run command"),
+		),
+		ClearInput: key.NewBinding(
+			key.WithKeys("ctrl+c"),
+			key.WithHelp("ctrl+c", "clear input"),
+		),
+		HistoryPrevious: key.NewBinding(
+			key.WithKeys("up"),
+			key.WithHelp("↑", "previous command"),
+		),
+		HistoryNext: key.NewBinding(
+			key.WithKeys("down"),
+			key.WithHelp("↓", "next command"),
+		),
+		ReverseSearch: key.NewBinding(
+			key.WithKeys("ctrl+r"),
+			key.WithHelp("ctrl+r", "search history"),
+		),
+
+		WordBackward: key.NewBinding(
+			key.WithKeys("alt+b", "alt+left"),
+			key.WithHelp("alt+b", "word backward"),
+		),
+		WordForward: key.NewBinding(
+			key.WithKeys("alt+f", "alt+right"),
+			key.WithHelp("alt+f", "word forward"),
+		),
+		KillWordBackward: key.NewBinding(
+			key.WithKeys("ctrl+w", "alt+backspace"),
+			key.WithHelp("ctrl+w", "kill word backward"),
+		),
+		KillWordForward: key.NewBinding(
+			key.WithKeys("alt+d", "alt+delete"),
+			key.WithHelp("alt+d", "kill word forward"),
+		),
+		KillToEnd: key.NewBinding(
+			key.WithKeys("ctrl+k"),
+			key.WithHelp("ctrl+k", "kill to end of line"),
+		),
+		KillToStart: key.NewBinding(
+			key.WithKeys("ctrl+u"),
+			key.WithHelp("ctrl+u", "kill to start of line"),
+		),
+		Yank: key.NewBinding(
+			key.WithKeys("ctrl+y"),
+			key.WithHelp("ctrl+y", "yank"),
+		),
+		YankPop: key.NewBinding(
+			key.WithKeys("alt+y"),
+			key.WithHelp("alt+y", "yank previous kill"),
+		),
+		Transpose: key.NewBinding(
+			key.WithKeys("ctrl+t"),
+			key.WithHelp("ctrl+t", "transpose characters"),
+		),
+		UpperCaseWord: key.NewBinding(
+			key.WithKeys("alt+u"),
+			key.WithHelp("alt+u", "upper case word"),
+		),
+		LowerCaseWord: key.NewBinding(
+			key.WithKeys("alt+l"),
+			key.WithHelp("alt+l", "lower case word"),
+		),
+		CapitalizeWord: key.NewBinding(
+			key.WithKeys("alt+c"),
+			key.WithHelp("alt+c", "capitalize word"),
+		),
+		Undo: key.NewBinding(
+			key.WithKeys("ctrl+_"),
+			key.WithHelp("ctrl+_", "undo"),
+		),
+		EditorPrefix: key.NewBinding(
+			key.WithKeys("ctrl+x"),
+			key.WithHelp("ctrl+x", "prefix"),
+		),
+		EditInEditor: key.NewBinding(
+			key.WithKeys("ctrl+e"),
+			key.WithHelp("ctrl+x ctrl+e", "edit in $EDITOR"),
+		),
+	}
+}
+
+type namedBinding struct {
+	name    string
+	binding key.Binding
+}
+
+// promptBindings are the bindings active while typing in the prompt.
+func (km KeyMap) promptBindings() []namedBinding {
+	bindings := []namedBinding{
+		{"Quit", km.Quit},
+		{"EnterInteraction", km.EnterInteraction},
+		{"Execute", km.Execute},
+		{"ClearInput", km.ClearInput},
+		{"HistoryPrevious", km.HistoryPrevious},
+		{"HistoryNext", km.HistoryNext},
+		{"ReverseSearch", km.ReverseSearch},
+		{"WordBackward", km.WordBackward},
+		{"WordForward", km.WordForward},
+		{"KillWordBackward", km.KillWordBackward},
+		{"KillWordForward", km.KillWordForward},
+		{"KillToEnd", km.KillToEnd},
+		{"KillToStart", km.KillToStart},
+		{"Yank", km.Yank},
+		{"YankPop", km.YankPop},
+		{"Transpose", km.Transpose},
+		{"UpperCaseWord", km.UpperCaseWord},
+		{"LowerCaseWord", km.LowerCaseWord},
+		{"CapitalizeWord", km.CapitalizeWord},
+		{"Undo", km.Undo},
+		{"EditorPrefix", km.EditorPrefix},
+	}
+
+	// after the prefix, EditInEditor is the only binding active
+	if !km.EditorPrefix.Enabled() {
+		bindings = append(bindings, namedBinding{"EditInEditor", km.EditInEditor})
+	}
+
+	return bindings
+}
+
+// interactionBindings are the bindings active while interacting with a
+// result.
+func (km KeyMap) interactionBindings() []namedBinding {
+	return []namedBinding{
+		{"LeaveInteraction", km.LeaveInteraction},
+		{"QuitInteraction", km.QuitInteraction},
+		{"Select", km.Select},
+		{"Back", km.Back},
+		{"LineUp", km.LineUp},
+		{"LineDown", km.LineDown},
+		{"PageUp", km.PageUp},
+		{"PageDown", km.PageDown},
+		{"HalfPageUp", km.HalfPageUp},
+		{"HalfPageDown", km.HalfPageDown},
+		{"GotoTop", km.GotoTop},
+		{"GotoBottom", km.GotoBottom},
+		{"ToggleSelection", km.ToggleSelection},
+		{"SelectAll", km.SelectAll},
+		{"ToggleGroup", km.ToggleGroup},
+		{"SortByColumn", km.SortByColumn},
+		{"ClearSort", km.ClearSort},
+		{"CopyRows", km.CopyRows},
+		{"SaveRows", km.SaveRows},
+		{"Expand", km.Expand},
+		{"Collapse", km.Collapse},
+		{"Filter", km.Filter},
+		{"Search", km.Search},
+		{"NextMatch", km.NextMatch},
+		{"PreviousMatch", km.PreviousMatch},
+	}
+}
+
+// queryBindings are the bindings active while typing a query in a table or
+// a tree, or the file to save the rows of a table to. ClearFilter is also
+// active in interactive results with a filter, where it takes the place of
+// Back.
+func (km KeyMap) queryBindings() []namedBinding {
+	return []namedBinding{
+		{"AcceptFilter", km.AcceptFilter},
+		{"ClearFilter", km.ClearFilter},
+	}
+}
+
+// Validate returns an error if a key is bound to two actions that are
+// active at the same time, e.g. two actions of the prompt, or if an action
+// cannot be triggered. The same key can be used in the prompt and in
+// interactive results, as Execute and Select are by default.
+func (km KeyMap) Validate() error {
+	if !km.Confirm.Enabled() {
+		return errors.New("actions cannot be confirmed: Confirm has no keys")
+	}
+
+	collisions := []string{}
+	groups := [][]namedBinding{km.promptBindings(), km.interactionBindings(), km.queryBindings()}
+	for _, bindings := range groups {
+		owners := map[string]string{}
+		for _, b := range bindings {
+			if !b.binding.Enabled() {
+				continue
+			}
+
+			for _, k := range b.binding.Keys() {
+				if owner, ok := owners[k]; ok && owner != b.name {
+					collisions = append(collisions, fmt.Sprintf("%q is bound to %s and %s", k, owner, b.name))
+					continue
+				}
+				owners[k] = b.name
+			}
+		}
+	}
+
+	if len(collisions) > 0 {
+		return fmt.Errorf("conflicting key bindings: %s", strings.Join(collisions, ", "))
+	}
+
+	return nil
+}
+
+// interactionHelpKeys are the bindings shown in the help of interactive
+// results.
+func (km KeyMap) interactionHelpKeys() []key.Binding {
+	return []key.Binding{km.Select, km.QuitInteraction, km.LeaveInteraction}
+}
@@ -0,0 +1,138 @@
+package vorl
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+func TestKeyMapValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(km *KeyMap)
+		want   string
+	}{
+		{"default", func(km *KeyMap) {}, ""},
+		{
+			"prompt collision",
+			func(km *KeyMap) { km.EnterInteraction = key.NewBinding(key.WithKeys("ctrl+k")) },
+			`"ctrl+k" is bound to EnterInteraction and KillToEnd`,
+		},
+		{
+			"interaction collision",
+			func(km *KeyMap) { km.PageUp = key.NewBinding(key.WithKeys("j")) },
+			`"j" is bound to LineDown and PageUp`,
+		},
+		{
+			"tree collision",
+			func(km *KeyMap) { km.Expand = key.NewBinding(key.WithKeys("j")) },
+			`"j" is bound to LineDown and Expand`,
+		},
+		{
+			"table filter collision",
+			func(km *KeyMap) { km.NextMatch = key.NewBinding(key.WithKeys("y")) },
+			`"y" is bound to CopyRows and NextMatch`,
+		},
+		{
+			"query collision",
+			func(km *KeyMap) { km.ClearFilter = key.NewBinding(key.WithKeys("enter")) },
+			`"enter" is bound to AcceptFilter and ClearFilter`,
+		},
+		{
+			"confirm shares a key with an action",
+			func(km *KeyMap) { km.Confirm = key.NewBinding(key.WithKeys("j")) },
+			"",
+		},
+		{
+			"confirm without keys",
+			func(km *KeyMap) { km.Confirm = key.NewBinding() },
+			"Confirm has no keys",
+		},
+		{
+			"disabled binding",
+			func(km *KeyMap) {
+				km.EnterInteraction = key.NewBinding(key.WithKeys("ctrl+k"))
+				km.EnterInteraction.SetEnabled(false)
+			},
+			"",
+		},
+		{
+			"prompt and interaction share a key",
+			func(km *KeyMap) { km.QuitInteraction = key.NewBinding(key.WithKeys("ctrl+r")) },
+			"",
+		},
+		{
+			"editor binding without prefix",
+			func(km *KeyMap) {
+				km.EditorPrefix.SetEnabled(false)
+				km.EditInEditor = key.NewBinding(key.WithKeys("ctrl+t"))
+			},
+			`"ctrl+t" is bound to Transpose and EditInEditor`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			km := DefaultKeyMap()
+			tt.change(&km)
+
+			err := km.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Validate() = %v, want no error", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Errorf("Validate() = %v, want an error containing %s", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyMapMovesTableCursor(t *testing.T) {
+	km := DefaultKeyMap()
+	km.LineDown = key.NewBinding(key.WithKeys("x"))
+
+	rt := newTable([][]string{{"a"}, {"1"}, {"2"}}, nil, nil, 80, 30, km)
+	rt.SetInteractiveMode(true)
+	rt, _ = rt.Update(runes("x"))
+
+	if rt.table.Cursor() != 1 {
+		t.Errorf("cursor = %d, want 1", rt.table.Cursor())
+	}
+}
+
+func TestKeyMapExpandsTree(t *testing.T) {
+	km := DefaultKeyMap()
+	km.Expand = key.NewBinding(key.WithKeys("x"))
+
+	tree := newTree([]TreeNode{{Name: "a", Children: []TreeNode{{Name: "b"}}}}, 80, 30, km)
+	tree.SetInteractiveMode(true)
+	tree, _ = tree.Update(runes("l"))
+	if len(tree.rows) != 1 {
+		t.Fatalf("the node was expanded by a key that is not bound")
+	}
+
+	tree, _ = tree.Update(runes("x"))
+	if len(tree.rows) != 2 {
+		t.Errorf("the node was not expanded")
+	}
+}
+
+func TestKeyMapConfirmsAction(t *testing.T) {
+	km := DefaultKeyMap()
+	km.Confirm = key.NewBinding(key.WithKeys("o"))
+
+	run := func(selected []string) interface{} { return nil }
+	rt := newTable([][]string{{"a"}, {"1"}}, nil, []TableAction{{Key: "x", Name: "delete", Confirm: true, Run: run}}, 80, 30, km)
+	rt.SetInteractiveMode(true)
+
+	rt, _ = rt.Update(runes("x"))
+	if view := rt.View(); !strings.Contains(view, "[o/N]") {
+		t.Errorf("the confirmation does not show its key:\n%s", view)
+	}
+
+	rt, _ = rt.Update(runes("o"))
+	if !rt.ExecutedCommand() {
+		t.Error("the action was not run after it was confirmed")
+	}
+}
@@ -1,72 +1,165 @@
 package vorl
 
 import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 type replList struct {
-	items           []string
+	items           []ListItem
 	fn              func(string) interface{}
+	fnMany          func([]string) interface{}
 	list            list.Model
 	width           int
 	height          int
 	interactiveMode bool
 	executedCommand bool
+	keyMap          KeyMap
+
+	// itemHeight is 2 when the items have descriptions
+	itemHeight int
+
+	// grouped is true when the items have groups
+	grouped   bool
+	collapsed map[string]bool
+
+	actions        []ListAction
+	actionBindings []key.Binding
+	pendingAction  *pendingAction
+
+	// pager is set when the items are fetched in pages
+	pager     *pager
+	fetchPage func(offset int) tea.Cmd
+
+	// live is set when the interpreter sends new items
+	live *liveSubscription
+
+	preview   *previewPane
+	previewFn func(string) string
 }
 
 func newList(
-	it []string,
+	it []ListItem,
 	fn func(string) interface{},
+	fnMany func([]string) interface{},
+	actions []ListAction,
 	width int,
 	height int,
+	keyMap KeyMap,
 ) replList {
-	items := make([]list.Item, len(it))
-	for i, item := range it {
-		items[i] = listItem(item)
-	}
+	entries := listEntries(it, fnMany != nil, nil)
+	itemHeight := listItemHeight(it)
+	grouped := isGrouped(it)
 
-	delegate := list.NewDefaultDelegate()
-	delegate.ShowDescription = false
-	delegate.SetHeight(1)
-	delegate.SetSpacing(0)
+	delegate := newListDelegate(itemHeight)
 	delegate.Styles.SelectedTitle = delegate.Styles.NormalTitle
+	delegate.Styles.SelectedDesc = delegate.Styles.NormalDesc
 
 	height -= 4
-	listHeight := min(len(it), height)
-	list := list.New(items, delegate, width, listHeight)
+	listHeight := min(len(entries)*itemHeight, height)
+	list := list.New(entries, delegate, width, listHeight)
 	list.SetShowTitle(false)
 	list.SetShowFilter(false)
 	list.SetFilteringEnabled(false)
 	list.DisableQuitKeybindings()
 	list.SetShowHelp(false)
 	list.SetShowStatusBar(false)
+	list.KeyMap.CursorUp = keyMap.LineUp
+	list.KeyMap.CursorDown = keyMap.LineDown
+	list.KeyMap.PrevPage = keyMap.PageUp
+	list.KeyMap.NextPage = keyMap.PageDown
+	list.KeyMap.GoToStart = keyMap.GotoTop
+	list.KeyMap.GoToEnd = keyMap.GotoBottom
 
-	if len(it) < height {
+	actionBindings := make([]key.Binding, len(actions))
+	for i, action := range actions {
+		actionBindings[i] = actionBinding(action.Key, action.Name)
+	}
+
+	helpKeys := func() []key.Binding {
+		keys := []key.Binding{}
+		if fnMany != nil {
+			keys = append(keys, keyMap.ToggleSelection, keyMap.SelectAll)
+		}
+		if grouped {
+			keys = append(keys, keyMap.ToggleGroup)
+		}
+		keys = append(keys, actionBindings...)
+		return append(keys, keyMap.interactionHelpKeys()...)
+	}
+	list.AdditionalShortHelpKeys = helpKeys
+	list.AdditionalFullHelpKeys = helpKeys
+
+	if grouped {
+		list.Filter = groupFilter(entries)
+	}
+
+	if len(entries) < height {
 		list.SetShowPagination(false)
 	}
 
-	return replList{
+	l := replList{
 		list:            list,
 		interactiveMode: false,
 		items:           it,
 		fn:              fn,
+		fnMany:          fnMany,
 		width:           width,
 		height:          height,
+		keyMap:          keyMap,
+		itemHeight:      itemHeight,
+		grouped:         grouped,
+		collapsed:       map[string]bool{},
+		actions:         actions,
+		actionBindings:  actionBindings,
 	}
+	l.skipHeaders(1)
+
+	return l
+}
+
+// listItemHeight returns 2 if any item has a description, and 1 otherwise.
+func listItemHeight(items []ListItem) int {
+	for _, item := range items {
+		if item.Description != "" {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+func newListDelegate(itemHeight int) listDelegate {
+	delegate := list.NewDefaultDelegate()
+	delegate.ShowDescription = itemHeight > 1
+	delegate.SetHeight(itemHeight)
+	delegate.SetSpacing(0)
+
+	return listDelegate{delegate}
 }
 
 func (l replList) View() string {
-	return l.list.View()
+	view := withPagerView(l.list.View(), l.pager)
+
+	if l.interactiveMode && l.preview != nil {
+		view += "\n" + l.preview.View(l.width)
+	}
+
+	if l.pendingAction != nil {
+		return view + "\n" + l.pendingAction.View(l.keyMap.Confirm)
+	}
+
+	return view
 }
 
 func (l replList) Update(msg tea.Msg) (replList, tea.Cmd) {
 	l.executedCommand = false
 
-	delegate := list.NewDefaultDelegate()
-	delegate.ShowDescription = false
-	delegate.SetHeight(1)
-	delegate.SetSpacing(0)
+	delegate := newListDelegate(l.itemHeight)
 	l.list.SetDelegate(delegate)
 
 	if !l.interactiveMode {
@@ -75,7 +168,8 @@ func (l replList) Update(msg tea.Msg) (replList, tea.Cmd) {
 		l.list.SetShowFilter(false)
 		l.list.SetFilteringEnabled(false)
 		delegate.Styles.SelectedTitle = delegate.Styles.NormalTitle
-		l.list.SetHeight(min(len(l.items), l.height))
+		delegate.Styles.SelectedDesc = delegate.Styles.NormalDesc
+		l.list.SetHeight(min(len(l.list.Items())*l.itemHeight, l.height))
 		l.list.SetDelegate(delegate)
 
 		return l, nil
@@ -85,25 +179,98 @@ func (l replList) Update(msg tea.Msg) (replList, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEnter:
+		if l.pendingAction != nil {
+			if key.Matches(msg, l.keyMap.Confirm) {
+				cmds = append(cmds, selectCmd(l.list.View(), l.pendingAction.label, l.pendingAction.run))
+				l.executedCommand = true
+			}
+			l.pendingAction = nil
+			return l, tea.Batch(cmds...)
+		}
+
+		if !l.list.SettingFilter() {
+			if item, ok := l.list.SelectedItem().(listItem); ok {
+				for i, binding := range l.actionBindings {
+					if !key.Matches(msg, binding) {
+						continue
+					}
+
+					action := l.actions[i]
+					value := item.item.value()
+					run := func() interface{} {
+						return action.Run(value)
+					}
+
+					if action.Confirm {
+						l.pendingAction = &pendingAction{
+							name:  fmt.Sprintf("%s on %s", action.Name, item.item.Title),
+							label: item.item.Title,
+							run:   run,
+						}
+					} else {
+						cmds = append(cmds, selectCmd(l.list.View(), item.item.Title, run))
+						l.executedCommand = true
+					}
+
+					return l, tea.Batch(cmds...)
+				}
+			}
+		}
+
+		switch {
+		case key.Matches(msg, l.keyMap.Select):
 			if l.list.FilterState() == list.Filtering {
 				// let the list filter handle the event
 				break
 			}
 
+			// selecting a collapsed group expands it
+			if _, ok := l.list.SelectedItem().(listHeader); ok {
+				return l, l.toggleGroup()
+			}
+
+			item, ok := l.list.SelectedItem().(listItem)
+			if !ok {
+				break
+			}
+
+			// execute the command associated to the selected items
+			if l.fnMany != nil {
+				selected := l.selectedValues()
+				label := strings.Join(selected, ", ")
+				cmds = append(cmds, selectCmd(l.list.View(), label, func() interface{} {
+					return l.fnMany(selected)
+				}))
+				l.executedCommand = true
+				break
+			}
+
 			// execute the command associated to the item
 			if l.fn != nil {
-				cmds = append(cmds, tea.Println(l.list.View()))
-				cmds = append(cmds, func() tea.Msg {
-					msg := l.fn(string(l.list.SelectedItem().(listItem)))
-					if msg == nil {
-						msg = CommandResultSimple("")
-					}
-					return msg
-				})
+				cmds = append(cmds, selectCmd(l.list.View(), item.item.Title, func() interface{} {
+					return l.fn(item.item.value())
+				}))
 				l.executedCommand = true
 			}
+
+		case l.fnMany != nil && !l.list.SettingFilter() && key.Matches(msg, l.keyMap.ToggleSelection):
+			if item, ok := l.list.SelectedItem().(listItem); ok {
+				item.selected = !item.selected
+				cmds = append(cmds, l.list.SetItem(item.index, item))
+				l.list.CursorDown()
+				l.skipHeaders(1)
+			}
+			cmds = append(cmds, l.updatePreview(msg))
+			return l, tea.Batch(cmds...)
+
+		case l.fnMany != nil && !l.list.SettingFilter() && key.Matches(msg, l.keyMap.SelectAll):
+			cmds = append(cmds, l.selectAllVisible())
+			return l, tea.Batch(cmds...)
+
+		case l.grouped && l.list.FilterState() == list.Unfiltered && key.Matches(msg, l.keyMap.ToggleGroup):
+			cmds = append(cmds, l.toggleGroup())
+			cmds = append(cmds, l.updatePreview(msg))
+			return l, tea.Batch(cmds...)
 		}
 	}
 
@@ -111,15 +278,97 @@ func (l replList) Update(msg tea.Msg) (replList, tea.Cmd) {
 	l.list.SetFilteringEnabled(true)
 	l.list.SetShowHelp(true)
 	l.list.SetShowStatusBar(true)
-	l.list.SetHeight(min(len(l.items)+5, l.height))
+	l.list.SetHeight(min(len(l.list.Items())*l.itemHeight+5, l.height))
+
+	before := l.list.Index()
+	wasFiltered := l.list.FilterState() != list.Unfiltered
+	current := l.list.SelectedItem()
 
 	var cmd tea.Cmd
 	l.list, cmd = l.list.Update(msg)
 	cmds = append(cmds, cmd)
 
+	// collapsed groups are expanded while filtering, so their items can be
+	// matched, and collapsed again once the filter is cleared
+	if l.grouped && wasFiltered != (l.list.FilterState() != list.Unfiltered) {
+		cmds = append(cmds, l.rebuild(func(entry list.Item) bool {
+			return current != nil && sameListEntry(entry, current)
+		}))
+	}
+
+	if l.list.Index() < before {
+		l.skipHeaders(-1)
+	} else {
+		l.skipHeaders(1)
+	}
+	cmds = append(cmds, l.nextPage(msg))
+	cmds = append(cmds, l.updatePreview(msg))
+
 	return l, tea.Batch(cmds...)
 }
 
+// setPreview shows the preview of the item under the cursor in interactive
+// mode, under the list.
+func (l *replList) setPreview(fn func(string) string) {
+	l.preview = newPreviewPane()
+	l.previewFn = fn
+	l.height = max(l.height-previewHeight-1, 1)
+}
+
+func (l *replList) updatePreview(msg tea.Msg) tea.Cmd {
+	if l.preview == nil {
+		return nil
+	}
+
+	cmd := l.preview.handle(msg)
+
+	item, ok := l.list.SelectedItem().(listItem)
+	if !ok {
+		l.preview.clear()
+		return cmd
+	}
+
+	value, fn := item.item.value(), l.previewFn
+	return tea.Batch(cmd, l.preview.update(value, func() string {
+		return fn(value)
+	}))
+}
+
+// setPager makes the list fetch the next page when the cursor gets close to
+// the last loaded item.
+func (l *replList) setPager(p *pager, fetchPage func(offset int) tea.Cmd) {
+	l.pager = p
+	l.fetchPage = fetchPage
+}
+
+// nextPage fetches the next page if it is needed, and again after it failed
+// once a key is pressed. Filtered lists only show the items already loaded.
+func (l *replList) nextPage(msg tea.Msg) tea.Cmd {
+	if l.pager == nil || l.list.FilterState() != list.Unfiltered {
+		return nil
+	}
+
+	l.pager.scrolled(msg)
+
+	if !l.pager.needsPage(l.list.Index()) {
+		return nil
+	}
+
+	l.pager.loading = true
+	return l.fetchPage(l.pager.loaded)
+}
+
+// appendPage adds the items of a page fetched in the background.
+func (l replList) appendPage(items []ListItem) (replList, tea.Cmd) {
+	current := l.list.SelectedItem()
+	l.items = append(l.items, items...)
+	cmd := l.rebuild(func(entry list.Item) bool {
+		return sameListEntry(entry, current)
+	})
+
+	return l, cmd
+}
+
 func (l replList) ExecutedCommand() bool {
 	return l.executedCommand
 }
@@ -129,11 +378,105 @@ func (l replList) RunSelected() (tea.Cmd, bool) {
 		return nil, false
 	}
 
+	item, ok := l.list.SelectedItem().(listItem)
+	if !ok {
+		return nil, false
+	}
+
 	return func() tea.Msg {
-		return l.fn(string(l.list.SelectedItem().(listItem)))
+		return l.fn(item.item.value())
 	}, true
 }
 
+// replaceItems shows the items sent by the interpreter, keeping the cursor
+// on the item with the same value. Filtered lists keep the cursor where it
+// is, as the matches are updated later.
+func (l replList) replaceItems(items []ListItem) (replList, tea.Cmd) {
+	current, hasCurrent := l.list.SelectedItem().(listItem)
+
+	l.items = items
+	l.itemHeight = listItemHeight(items)
+	cmd := l.rebuild(func(entry list.Item) bool {
+		item, ok := entry.(listItem)
+		return ok && hasCurrent && item.item.value() == current.item.value()
+	})
+
+	if l.preview == nil {
+		return l, cmd
+	}
+
+	// items with the same value may have changed, and so their previews
+	l.preview.reset()
+	if l.interactiveMode {
+		cmd = tea.Batch(cmd, l.updatePreview(nil))
+	}
+
+	return l, cmd
+}
+
+// sameListEntry returns true if both entries are the same item or header,
+// after the entries are built again.
+func sameListEntry(a list.Item, b list.Item) bool {
+	switch a := a.(type) {
+	case listItem:
+		b, ok := b.(listItem)
+		return ok && a.pos == b.pos
+
+	case listHeader:
+		b, ok := b.(listHeader)
+		return ok && a.name == b.name
+	}
+
+	return false
+}
+
+// selectedValues returns the values of the selected items that match the
+// filter, or the item under the cursor if none of them is selected. Items
+// hidden by the filter keep their selection for when it is cleared.
+func (l replList) selectedValues() []string {
+	selected := []string{}
+	for _, it := range l.list.VisibleItems() {
+		if item, ok := it.(listItem); ok && item.selected {
+			selected = append(selected, item.item.value())
+		}
+	}
+
+	if len(selected) == 0 {
+		if item, ok := l.list.SelectedItem().(listItem); ok {
+			selected = append(selected, item.item.value())
+		}
+	}
+
+	return selected
+}
+
+// selectAllVisible selects every item that matches the filter, or
+// unselects them if all of them are already selected.
+func (l *replList) selectAllVisible() tea.Cmd {
+	visible := l.list.VisibleItems()
+
+	allSelected := true
+	for _, it := range visible {
+		if item, ok := it.(listItem); ok && !item.selected {
+			allSelected = false
+			break
+		}
+	}
+
+	cmds := []tea.Cmd{}
+	for _, it := range visible {
+		item, ok := it.(listItem)
+		if !ok {
+			continue
+		}
+
+		item.selected = !allSelected
+		cmds = append(cmds, l.list.SetItem(item.index, item))
+	}
+
+	return tea.Batch(cmds...)
+}
+
 func (l *replList) SetInteractiveMode(m bool) {
 	// TODO: sacar esto por ahi, solo checkear si hay posibilidad de scroll
 	/*
@@ -144,6 +487,13 @@ func (l *replList) SetInteractiveMode(m bool) {
 	*/
 
 	l.interactiveMode = m
+	l.pendingAction = nil
+
+	// a preview requested before leaving is dropped, so it has to be
+	// requested again when coming back
+	if !m && l.preview != nil {
+		l.preview.clear()
+	}
 }
 
 func (l replList) InteractiveMode() bool {
@@ -154,16 +504,76 @@ func (l replList) SettingFilter() bool {
 	return l.list.SettingFilter()
 }
 
-type listItem string
+// Filtered returns true if a filter is being typed or applied.
+func (l replList) Filtered() bool {
+	return l.list.FilterState() != list.Unfiltered
+}
+
+// ListItem is an item of a CommandResultList.
+type ListItem struct {
+	Title string
+
+	// Description is shown in a second line under the title.
+	Description string
+
+	// Icon is shown before the title, e.g. a status badge.
+	Icon string
+
+	// Value is passed to OnSelect and OnSelectMany instead of the title.
+	Value string
+
+	// FilterText is matched by the list filter instead of the title.
+	FilterText string
+
+	// Group shows the item under a header with the other items of the same
+	// group. Groups are shown in the order they first appear, after the
+	// items without a group. Groups can be collapsed in interactive mode,
+	// and are expanded while the list is filtered.
+	Group string
+}
+
+func (i ListItem) value() string {
+	if i.Value != "" {
+		return i.Value
+	}
+	return i.Title
+}
+
+type listItem struct {
+	item ListItem
+
+	// index is the position of the item in the unfiltered list, and pos
+	// its position in the items of the result
+	index int
+	pos   int
+
+	multiSelect bool
+	selected    bool
+}
 
 func (l listItem) FilterValue() string {
-	return string(l)
+	if l.item.FilterText != "" {
+		return l.item.FilterText
+	}
+	return l.item.Title
 }
 
 func (l listItem) Title() string {
-	return string(l)
+	title := l.item.Title
+	if l.item.Icon != "" {
+		title = l.item.Icon + " " + title
+	}
+
+	if !l.multiSelect {
+		return title
+	}
+
+	if l.selected {
+		return "[x] " + title
+	}
+	return "[ ] " + title
 }
 
 func (l listItem) Description() string {
-	return ""
+	return l.item.Description
 }
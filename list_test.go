@@ -0,0 +1,41 @@
+package vorl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSaveMultiSelectList(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := filepath.Join(t.TempDir(), "items.txt")
+	newModel, _ := r.model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	_, cmd := newModel.Update(CommandResultSaveTo{
+		File: file,
+		Result: CommandResultList{
+			List:         []string{"a", "b"},
+			OnSelectMany: func([]string) interface{} { return nil },
+		},
+	})
+	if cmd == nil {
+		t.Fatal("the list was not saved")
+	}
+	if msg := cmd(); msg != nil {
+		t.Fatalf("saving the list failed: %v", msg)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "[ ]") {
+		t.Errorf("saved the selection marks: %q", content)
+	}
+}
@@ -0,0 +1,244 @@
+package vorl
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// listHeader is the header of a group of items. Headers can only be
+// selected while their group is collapsed, to expand it.
+type listHeader struct {
+	name      string
+	count     int
+	collapsed bool
+}
+
+func (h listHeader) FilterValue() string {
+	return h.name
+}
+
+func isGrouped(items []ListItem) bool {
+	for _, item := range items {
+		if item.Group != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listEntries builds the entries of the list. When the items have groups,
+// items without one come first, in their order, and then every group under
+// its header in the order they first appear. Items of collapsed groups are
+// left out.
+func listEntries(items []ListItem, multiSelect bool, collapsed map[string]bool) []list.Item {
+	entries := []list.Item{}
+	addItem := func(pos int) {
+		entries = append(entries, listItem{
+			item:        items[pos],
+			index:       len(entries),
+			pos:         pos,
+			multiSelect: multiSelect,
+		})
+	}
+
+	groups := []string{}
+	members := map[string][]int{}
+	for i, item := range items {
+		if item.Group == "" {
+			addItem(i)
+			continue
+		}
+
+		if _, ok := members[item.Group]; !ok {
+			groups = append(groups, item.Group)
+		}
+		members[item.Group] = append(members[item.Group], i)
+	}
+
+	for _, group := range groups {
+		entries = append(entries, listHeader{
+			name:      group,
+			count:     len(members[group]),
+			collapsed: collapsed[group],
+		})
+
+		if collapsed[group] {
+			continue
+		}
+
+		for _, pos := range members[group] {
+			addItem(pos)
+		}
+	}
+
+	return entries
+}
+
+// groupFilter matches the items as the default filter does, keeping them in
+// their groups under the headers of the groups with matches. Groups are
+// not collapsed while filtering, so headers are never matched themselves.
+func groupFilter(entries []list.Item) list.FilterFunc {
+	// headers[i] is the header of the group of the entry i, or -1
+	headers := make([]int, len(entries))
+	header := -1
+	for i, entry := range entries {
+		if _, ok := entry.(listHeader); ok {
+			header = i
+		}
+		headers[i] = header
+	}
+
+	return func(term string, targets []string) []list.Rank {
+		ranks := list.DefaultFilter(term, targets)
+		sort.Slice(ranks, func(i, j int) bool {
+			return ranks[i].Index < ranks[j].Index
+		})
+
+		result := []list.Rank{}
+		lastHeader := -1
+		for _, rank := range ranks {
+			if _, ok := entries[rank.Index].(listHeader); ok {
+				continue
+			}
+
+			// the header is added before the first match of its group
+			if header := headers[rank.Index]; header >= 0 && header != lastHeader {
+				result = append(result, list.Rank{Index: header})
+				lastHeader = header
+			}
+			result = append(result, rank)
+		}
+
+		return result
+	}
+}
+
+// listDelegate renders group headers, and items as the default delegate.
+type listDelegate struct {
+	list.DefaultDelegate
+}
+
+func (d listDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	header, ok := item.(listHeader)
+	if !ok {
+		d.DefaultDelegate.Render(w, m, index, item)
+		return
+	}
+
+	title := "▾ " + header.name
+	if header.collapsed {
+		title = fmt.Sprintf("▸ %s (%d)", header.name, header.count)
+	}
+
+	style := d.Styles.NormalTitle.Copy().Foreground(lipgloss.Color("99"))
+	if index == m.Index() {
+		style = d.Styles.SelectedTitle.Copy()
+	}
+
+	fmt.Fprint(w, style.Bold(true).Render(title)+strings.Repeat("\n", d.Height()-1))
+}
+
+// setEntries shows the entries built by listEntries.
+func (l *replList) setEntries(entries []list.Item) tea.Cmd {
+	if l.grouped {
+		l.list.Filter = groupFilter(entries)
+	}
+
+	cmd := l.list.SetItems(entries)
+	l.list.SetShowPagination(len(entries) >= l.height)
+	if l.interactiveMode {
+		l.list.SetHeight(min(len(entries)*l.itemHeight+5, l.height))
+	}
+
+	return cmd
+}
+
+// rebuild builds the entries again after the items or the collapsed groups
+// change, keeping the selected items and moving the cursor to the first
+// entry matched by isCursor.
+func (l *replList) rebuild(isCursor func(list.Item) bool) tea.Cmd {
+	selected := map[int]bool{}
+	for _, it := range l.list.Items() {
+		if item, ok := it.(listItem); ok && item.selected {
+			selected[item.pos] = true
+		}
+	}
+
+	collapsed := l.collapsed
+	if l.list.FilterState() != list.Unfiltered {
+		collapsed = nil
+	}
+
+	l.grouped = isGrouped(l.items)
+	entries := listEntries(l.items, l.fnMany != nil, collapsed)
+	cursor := -1
+	for i, entry := range entries {
+		if item, ok := entry.(listItem); ok && selected[item.pos] {
+			item.selected = true
+			entries[i] = item
+		}
+
+		if cursor < 0 && isCursor(entry) {
+			cursor = i
+		}
+	}
+
+	cmd := l.setEntries(entries)
+	if cursor >= 0 && l.list.FilterState() == list.Unfiltered {
+		l.list.Select(cursor)
+	}
+	l.skipHeaders(1)
+
+	return cmd
+}
+
+// skipHeaders moves the cursor in the given direction until it is not on
+// the header of an expanded group, turning back at the ends of the list.
+func (l *replList) skipHeaders(direction int) {
+	for range l.list.VisibleItems() {
+		header, ok := l.list.SelectedItem().(listHeader)
+		if !ok || header.collapsed {
+			return
+		}
+
+		before := l.list.Index()
+		if direction > 0 {
+			l.list.CursorDown()
+		} else {
+			l.list.CursorUp()
+		}
+
+		if l.list.Index() == before {
+			direction = -direction
+		}
+	}
+}
+
+// toggleGroup collapses the group of the item under the cursor, or expands
+// the collapsed group under it.
+func (l *replList) toggleGroup() tea.Cmd {
+	var group string
+	switch entry := l.list.SelectedItem().(type) {
+	case listItem:
+		group = entry.item.Group
+	case listHeader:
+		group = entry.name
+	}
+
+	if group == "" {
+		return nil
+	}
+
+	l.collapsed[group] = !l.collapsed[group]
+	return l.rebuild(func(entry list.Item) bool {
+		header, ok := entry.(listHeader)
+		return ok && header.name == group
+	})
+}
@@ -0,0 +1,123 @@
+package vorl
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func titles(entries []list.Item) []string {
+	result := []string{}
+	for _, entry := range entries {
+		switch entry := entry.(type) {
+		case listItem:
+			result = append(result, entry.item.Title)
+		case listHeader:
+			result = append(result, "["+entry.name+"]")
+		}
+	}
+	return result
+}
+
+func TestFilterExpandsCollapsedGroups(t *testing.T) {
+	items := []ListItem{
+		{Title: "apple", Group: "fruit"},
+		{Title: "carrot", Group: "vegetable"},
+	}
+	l := newList(items, func(string) interface{} { return nil }, nil, nil, 80, 40, DefaultKeyMap())
+	l.SetInteractiveMode(true)
+	l.collapsed["vegetable"] = true
+	l.rebuild(func(list.Item) bool { return false })
+
+	l, _ = l.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+
+	got := titles(l.list.Items())
+	want := []string{"[fruit]", "apple", "[vegetable]", "carrot"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("entries while filtering = %q, want %q", got, want)
+	}
+
+	l, _ = l.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if got := titles(l.list.Items()); len(got) != 3 {
+		t.Errorf("entries after clearing the filter = %q, want the group collapsed again", got)
+	}
+}
+
+func TestListEntries(t *testing.T) {
+	items := []ListItem{
+		{Title: "a1", Group: "a"},
+		{Title: "loose1"},
+		{Title: "b1", Group: "b"},
+		{Title: "a2", Group: "a"},
+		{Title: "loose2"},
+	}
+
+	tests := []struct {
+		name      string
+		items     []ListItem
+		collapsed map[string]bool
+		want      []string
+	}{
+		{"no groups", []ListItem{{Title: "x"}, {Title: "y"}}, nil, []string{"x", "y"}},
+		{"groups", items, nil, []string{"loose1", "loose2", "[a]", "a1", "a2", "[b]", "b1"}},
+		{"collapsed", items, map[string]bool{"a": true}, []string{"loose1", "loose2", "[a]", "[b]", "b1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries := listEntries(tt.items, false, tt.collapsed)
+			if got := titles(entries); !slices.Equal(got, tt.want) {
+				t.Errorf("entries = %q, want %q", got, tt.want)
+			}
+
+			// items know their index in the entries and in the input
+			for i, entry := range entries {
+				if item, ok := entry.(listItem); ok {
+					if item.index != i || tt.items[item.pos].Title != item.item.Title {
+						t.Errorf("item %q has index %d and pos %d", item.item.Title, item.index, item.pos)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestGroupFilter(t *testing.T) {
+	items := []ListItem{
+		{Title: "loose"},
+		{Title: "apple", Group: "fruit"},
+		{Title: "banana", Group: "fruit"},
+		{Title: "apricot", Group: "tree"},
+	}
+	entries := listEntries(items, false, nil)
+
+	targets := make([]string, len(entries))
+	for i, entry := range entries {
+		targets[i] = entry.FilterValue()
+	}
+
+	tests := []struct {
+		term string
+		want []string
+	}{
+		{"ap", []string{"[fruit]", "apple", "[tree]", "apricot"}},
+		{"banana", []string{"[fruit]", "banana"}},
+		{"loose", []string{"loose"}},
+		{"fruit", []string{}},
+		{"zzz", []string{}},
+	}
+
+	for _, tt := range tests {
+		ranks := groupFilter(entries)(tt.term, targets)
+
+		got := []string{}
+		for _, rank := range ranks {
+			got = append(got, titles([]list.Item{entries[rank.Index]})...)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("groupFilter(%q) = %q, want %q", tt.term, got, tt.want)
+		}
+	}
+}
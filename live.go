@@ -0,0 +1,177 @@
+package vorl
+
+import (
+	"slices"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// CommandResultLiveTable is a CommandResultTable whose rows are replaced
+// every time the interpreter sends them through Updates, until another
+// command is run.
+type CommandResultLiveTable struct {
+	// Table is shown until the first update, header included.
+	Table [][]string
+
+	// Updates receives the new rows, without the header.
+	Updates <-chan [][]string
+
+	// KeyColumn identifies a row, so the cursor stays on the same row
+	// across updates.
+	KeyColumn int
+
+	// Stop is called, if set, once the table is replaced, dropped from the
+	// results it was drilled down from, or saved or printed, so the
+	// interpreter can stop sending updates. Closing Updates also stops the
+	// table from listening.
+	Stop func()
+
+	OnSelect func(selected []string) interface{}
+	Actions  []TableAction
+	Preview  func(selected []string) string
+}
+
+// CommandResultLiveList is a CommandResultList whose items are replaced
+// every time the interpreter sends them through Updates, until another
+// command is run. Items are identified by their Value, or their title if
+// they have no value.
+type CommandResultLiveList struct {
+	// Items are shown until the first update.
+	Items []ListItem
+
+	Updates <-chan []ListItem
+
+	// Stop is called, if set, once the list is replaced, dropped from the
+	// results it was drilled down from, or saved or printed.
+	Stop func()
+
+	// OnSelect receives the selected item. Live lists do not allow
+	// selecting several items, since the items change while selecting.
+	OnSelect func(selected string) interface{}
+	Actions  []ListAction
+	Preview  func(selected string) string
+}
+
+// liveSubscription identifies the result that receives the updates of a
+// channel, so they are dropped once it is replaced.
+type liveSubscription struct {
+	stop   func()
+	closed bool
+}
+
+// close calls the Stop function of the result once.
+func (s *liveSubscription) close() {
+	if s.closed {
+		return
+	}
+	s.closed = true
+
+	if s.stop != nil {
+		s.stop()
+	}
+}
+
+type liveTableUpdate struct {
+	sub     *liveSubscription
+	updates <-chan [][]string
+	rows    [][]string
+}
+
+func waitForTableUpdate(sub *liveSubscription, updates <-chan [][]string) tea.Cmd {
+	return func() tea.Msg {
+		rows, ok := <-updates
+		if !ok {
+			return nil
+		}
+
+		return liveTableUpdate{sub: sub, updates: updates, rows: rows}
+	}
+}
+
+type liveListUpdate struct {
+	sub     *liveSubscription
+	updates <-chan []ListItem
+	items   []ListItem
+}
+
+func waitForListUpdate(sub *liveSubscription, updates <-chan []ListItem) tea.Cmd {
+	return func() tea.Msg {
+		items, ok := <-updates
+		if !ok {
+			return nil
+		}
+
+		return liveListUpdate{sub: sub, updates: updates, items: items}
+	}
+}
+
+// liveFrames returns the results hidden by drill-downs, including the one
+// an item is being selected from.
+func (m model) liveFrames() []resultFrame {
+	if m.state == replStateExecutingCommand {
+		return append([]resultFrame{m.selectedFrom}, m.resultStack...)
+	}
+
+	return m.resultStack
+}
+
+// liveSubscriptions returns the subscriptions of the live results shown or
+// hidden by drill-downs.
+func (m model) liveSubscriptions() []*liveSubscription {
+	frames := append([]resultFrame{{list: m.listResult, table: m.tableResult}}, m.liveFrames()...)
+
+	subs := []*liveSubscription{}
+	for _, frame := range frames {
+		if frame.list != nil && frame.list.live != nil {
+			subs = append(subs, frame.list.live)
+		}
+		if frame.table != nil && frame.table.live != nil {
+			subs = append(subs, frame.table.live)
+		}
+	}
+
+	return subs
+}
+
+// closeDropped stops the subscriptions of the live results that were
+// replaced, popped or cleared, which are in subs but no longer in m.
+func (m model) closeDropped(subs []*liveSubscription) {
+	kept := m.liveSubscriptions()
+	for _, sub := range subs {
+		if !slices.Contains(kept, sub) {
+			sub.close()
+		}
+	}
+}
+
+// liveList returns the list that receives the updates of sub, which is
+// either shown or hidden by a drill-down, or nil if it was replaced.
+func (m model) liveList(sub *liveSubscription) *replList {
+	if m.listResult != nil && m.listResult.live == sub {
+		return m.listResult
+	}
+
+	for _, frame := range m.liveFrames() {
+		if frame.list != nil && frame.list.live == sub {
+			return frame.list
+		}
+	}
+
+	return nil
+}
+
+// liveTable returns the table that receives the updates of sub, or nil if
+// it was replaced.
+func (m model) liveTable(sub *liveSubscription) *replTable {
+	if m.tableResult != nil && m.tableResult.live == sub {
+		return m.tableResult
+	}
+
+	for _, frame := range m.liveFrames() {
+		if frame.table != nil && frame.table.live == sub {
+			return frame.table
+		}
+	}
+
+	return nil
+}
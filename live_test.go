@@ -0,0 +1,82 @@
+package vorl
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestReplacedLiveTableIsStopped(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := 0
+	newModel, _ := r.model.Update(CommandResultLiveTable{
+		Table:   [][]string{{"name"}, {"a"}},
+		Updates: make(chan [][]string),
+		Stop:    func() { stopped++ },
+	})
+	if stopped != 0 {
+		t.Fatalf("the table was stopped while shown")
+	}
+
+	newModel, _ = newModel.Update(CommandResultSimple("done"))
+	newModel.Update(CommandResultSimple("done again"))
+
+	if stopped != 1 {
+		t.Errorf("Stop was called %d times, want 1", stopped)
+	}
+}
+
+func TestLiveTableWithoutRows(t *testing.T) {
+	table := newTable([][]string{{"name"}, {"a"}, {"b"}}, nil, nil, 80, 10, DefaultKeyMap())
+	table.table.SetCursor(1)
+
+	table, _ = table.replaceRows([][]string{})
+	if row := table.table.SelectedRow(); row != nil {
+		t.Errorf("selected row = %q in an empty table", row)
+	}
+
+	table, _ = table.replaceRows([][]string{{"c"}, {"d"}})
+	if got := table.table.Cursor(); got != 0 {
+		t.Errorf("cursor = %d after rows are shown again, want 0", got)
+	}
+}
+
+func TestLiveTableIsStoppedAfterGoingBack(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stopped := 0
+	newModel, _ := r.model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	newModel, _ = newModel.Update(CommandResultLiveTable{
+		Table:    [][]string{{"name"}, {"a"}},
+		Updates:  make(chan [][]string),
+		Stop:     func() { stopped++ },
+		OnSelect: func([]string) interface{} { return nil },
+	})
+	m, _ := newModel.(model).enterInteraction()
+
+	// drill down from the table and go back to it
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	details := CommandResultList{List: []string{"x"}}
+	newModel, _ = newModel.Update(resultSelected{label: "a", result: details})
+	newModel, _ = newModel.Update(details)
+	newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if stopped != 0 {
+		t.Fatalf("the table was stopped while hidden")
+	}
+
+	// the table is printed when the next command runs
+	newModel, _ = newModel.Update(runes("q"))
+	newModel, _ = newModel.Update(runes("ls"))
+	newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if stopped != 1 {
+		t.Errorf("Stop was called %d times, want 1", stopped)
+	}
+}
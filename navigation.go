@@ -0,0 +1,159 @@
+package vorl
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// resultSelected is sent when an item of an interactive result is selected,
+// with the result returned by its callback.
+type resultSelected struct {
+	// view is the result as it was when the item was selected. It is
+	// printed unless the new result is shown on top of it.
+	view string
+
+	// label names the item in the breadcrumb
+	label string
+
+	result tea.Msg
+}
+
+// selectCmd runs the callback of a selected item in the background.
+func selectCmd(view string, label string, fn func() interface{}) tea.Cmd {
+	return func() tea.Msg {
+		msg := fn()
+		if err := checkResult(msg); err != nil {
+			msg = commandError(err)
+		}
+		if msg == nil {
+			msg = CommandResultSimple("")
+		}
+
+		return resultSelected{view: view, label: label, result: msg}
+	}
+}
+
+// resultFrame is a result hidden by a drill-down. It is shown again as it
+// was, cursor and filter included, when going back.
+type resultFrame struct {
+	list  *replList
+	table *replTable
+	tree  *replTree
+
+	// label names the item selected to drill down
+	label string
+}
+
+// drillsDown returns true if the result of a selection is shown on top of
+// the result the item was selected from.
+func drillsDown(result tea.Msg) bool {
+	switch result.(type) {
+	case CommandResultList,
+		CommandResultTable,
+		CommandResultTree,
+		CommandResultPagedList,
+		CommandResultPagedTable,
+		CommandResultLiveList,
+		CommandResultLiveTable:
+
+		return true
+	}
+
+	return false
+}
+
+// resultSelectedUpdate pushes the result the item was selected from to the
+// navigation stack if the new result drills down, or prints it otherwise.
+func (m model) resultSelectedUpdate(msg resultSelected) (model, tea.Cmd) {
+	cmds := []tea.Cmd{}
+
+	if drillsDown(msg.result) {
+		frame := m.selectedFrom
+		frame.label = msg.label
+		m.resultStack = append(m.resultStack, frame)
+		m.drillingDown = true
+	} else {
+		cmds = append(cmds, tea.Println(m.withBreadcrumb(msg.view)))
+		m.resultStack = nil
+	}
+
+	// the result was pushed or printed, so it is not kept alive anymore
+	m.selectedFrom = resultFrame{}
+
+	result := msg.result
+	cmds = append(cmds, func() tea.Msg {
+		return result
+	})
+
+	return m, tea.Sequence(cmds...)
+}
+
+// back shows the result the current one was drilled down from.
+func (m model) back() (model, tea.Cmd) {
+	frame := m.resultStack[len(m.resultStack)-1]
+	m.resultStack = m.resultStack[:len(m.resultStack)-1]
+
+	m.listResult = frame.list
+	m.tableResult = frame.table
+	m.treeResult = frame.tree
+
+	m.lastInfo.Now = time.Now()
+	m = m.refreshPrompt()
+
+	// previews requested while the result was hidden were dropped
+	switch {
+	case m.listResult != nil && m.listResult.preview != nil:
+		m.listResult.preview.clear()
+	case m.tableResult != nil && m.tableResult.preview != nil:
+		m.tableResult.preview.clear()
+	}
+
+	return m.enterInteraction()
+}
+
+// canGoBack returns true if the Back keys go to the previous result, and
+// not to the result in focus, e.g. to clear its filter.
+func (m model) canGoBack() bool {
+	if len(m.resultStack) == 0 {
+		return false
+	}
+
+	switch m.state {
+	case replStateListInteraction:
+		return !m.listResult.Filtered()
+	case replStateTableInteraction:
+		return !m.tableResult.Filtered() && !m.tableResult.Saving()
+	case replStateTreeInteraction:
+		return !m.treeResult.Filtered()
+	}
+
+	return false
+}
+
+// breadcrumb shows the command and the items selected to reach the result
+// shown, e.g. "services › api › pods".
+func (m model) breadcrumb() string {
+	if len(m.resultStack) == 0 {
+		return ""
+	}
+
+	crumbs := []string{m.resultRoot}
+	for _, frame := range m.resultStack {
+		crumbs = append(crumbs, frame.label)
+	}
+
+	return lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Render(strings.Join(crumbs, " › "))
+}
+
+func (m model) withBreadcrumb(view string) string {
+	if breadcrumb := m.breadcrumb(); breadcrumb != "" {
+		return breadcrumb + "\n" + view
+	}
+
+	return view
+}
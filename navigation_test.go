@@ -0,0 +1,93 @@
+package vorl
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// drillDownModel returns a model showing the items of the second row of a
+// table, selected after running "services".
+func drillDownModel(t *testing.T) model {
+	t.Helper()
+
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModel, _ := r.model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	newModel, _ = newModel.Update(commandExecuted("services"))
+	newModel, _ = newModel.Update(CommandResultTable{
+		Table:    [][]string{{"name"}, {"web"}, {"api"}},
+		OnSelect: func([]string) interface{} { return nil },
+	})
+	m, _ := newModel.(model).enterInteraction()
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	pods := CommandResultList{List: []string{"pod-1", "pod-2"}}
+	newModel, _ = newModel.Update(resultSelected{label: "api", result: pods})
+	newModel, _ = newModel.Update(pods)
+
+	return newModel.(model)
+}
+
+func TestDrillDownBreadcrumb(t *testing.T) {
+	m := drillDownModel(t)
+
+	if m.state != replStateListInteraction {
+		t.Fatalf("the result of the selection is not in focus")
+	}
+	if got := ansi.Strip(m.breadcrumb()); got != "services › api" {
+		t.Errorf("breadcrumb = %q, want %q", got, "services › api")
+	}
+}
+
+func TestBackRestoresResult(t *testing.T) {
+	m := drillDownModel(t)
+
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+
+	if m.state != replStateTableInteraction || m.tableResult == nil || m.listResult != nil {
+		t.Fatalf("the table was not shown again")
+	}
+	if got := m.tableResult.table.Cursor(); got != 1 {
+		t.Errorf("cursor = %d, want the selected row", got)
+	}
+	if m.breadcrumb() != "" {
+		t.Errorf("breadcrumb = %q at the first result", m.breadcrumb())
+	}
+
+	// the first result has nothing to go back to
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if newModel.(model).tableResult == nil {
+		t.Error("the first result was dropped going back")
+	}
+}
+
+func TestBackKeyClearsFilterFirst(t *testing.T) {
+	m := drillDownModel(t)
+
+	newModel, _ := m.Update(runes("/"))
+	newModel, _ = newModel.Update(runes("pod-2"))
+	newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(model)
+	if m.canGoBack() {
+		t.Fatal("going back while the list is filtered")
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = newModel.(model)
+	if m.listResult == nil || m.listResult.Filtered() {
+		t.Fatal("the filter was not cleared")
+	}
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if newModel.(model).tableResult == nil {
+		t.Error("the table was not shown again after the filter was cleared")
+	}
+}
@@ -0,0 +1,108 @@
+package vorl
+
+// Option configures optional behaviour of a REPL created with NewREPL.
+type Option func(*replOptions)
+
+type replOptions struct {
+	redactionRules []RedactionRule
+	historyStore   HistoryStore
+	viMode         bool
+	keyMap         KeyMap
+
+	pasteMode         PasteMode
+	pasteConfirmAbove int
+
+	executeAfterEdit bool
+
+	promptFunc PromptFunc
+
+	statusBarFunc     StatusBarFunc
+	statusBarPosition StatusBarPosition
+
+	redirection bool
+	chaining    bool
+}
+
+// WithRedactionRules sets the rules applied to every command before it is
+// stored in the history.
+func WithRedactionRules(rules ...RedactionRule) Option {
+	return func(o *replOptions) {
+		o.redactionRules = append(o.redactionRules, rules...)
+	}
+}
+
+// WithViMode starts the REPL with vi editing mode enabled. It can also be
+// toggled at runtime with 'set -o vi' and 'set -o emacs'.
+func WithViMode() Option {
+	return func(o *replOptions) {
+		o.viMode = true
+	}
+}
+
+// WithKeyMap replaces the default key bindings. NewREPL fails if a key is
+// bound to two actions that are active at the same time.
+func WithKeyMap(keyMap KeyMap) Option {
+	return func(o *replOptions) {
+		o.keyMap = keyMap
+	}
+}
+
+// WithPasteMode sets what happens when several lines are pasted. With
+// PasteModeQueue a confirmation is asked when more than confirmAbove
+// commands are pasted; a negative value never asks.
+func WithPasteMode(mode PasteMode, confirmAbove int) Option {
+	return func(o *replOptions) {
+		o.pasteMode = mode
+		o.pasteConfirmAbove = confirmAbove
+	}
+}
+
+// WithExecuteAfterEdit runs the input edited in $EDITOR as soon as the
+// editor exits, instead of loading it back in the prompt.
+func WithExecuteAfterEdit() Option {
+	return func(o *replOptions) {
+		o.executeAfterEdit = true
+	}
+}
+
+// WithPromptFunc builds the prompt with fn instead of using the fixed prompt
+// given to NewREPL.
+func WithPromptFunc(fn PromptFunc) Option {
+	return func(o *replOptions) {
+		o.promptFunc = fn
+	}
+}
+
+// WithStatusBar shows a status line with the content returned by fn, at
+// the top or the bottom of the REPL.
+func WithStatusBar(fn StatusBarFunc, position StatusBarPosition) Option {
+	return func(o *replOptions) {
+		o.statusBarFunc = fn
+		o.statusBarPosition = position
+	}
+}
+
+// WithRedirection saves the result of commands ending in '> file' to that
+// file instead of showing it, as CommandResultSaveTo does.
+func WithRedirection() Option {
+	return func(o *replOptions) {
+		o.redirection = true
+	}
+}
+
+// WithChaining runs command lines with several commands joined by '&&',
+// '||' or ';' one after the other, as a shell does. With WithRedirection,
+// every command can be redirected on its own.
+func WithChaining() Option {
+	return func(o *replOptions) {
+		o.chaining = true
+	}
+}
+
+// WithHistoryStore sets where the history is loaded from and saved to. It
+// takes precedence over the history file given to NewREPL.
+func WithHistoryStore(store HistoryStore) Option {
+	return func(o *replOptions) {
+		o.historyStore = store
+	}
+}
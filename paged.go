@@ -0,0 +1,215 @@
+package vorl
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const defaultPageSize = 100
+
+// CommandResultPagedList is a CommandResultList whose items are fetched in
+// pages as the user scrolls, for results too big to load at once.
+type CommandResultPagedList struct {
+	// Fetch returns up to limit items starting at offset. A page shorter
+	// than limit is the last one.
+	Fetch func(offset int, limit int) ([]ListItem, error)
+
+	// Total is the number of items if it is known, and is only used to show
+	// the progress.
+	Total int
+
+	// PageSize is the limit passed to Fetch, 100 by default.
+	PageSize int
+
+	OnSelect func(selected string) interface{}
+
+	// OnSelectMany allows selecting several of the items loaded, and is
+	// called with all of them instead of OnSelect.
+	OnSelectMany func(selected []string) interface{}
+
+	Actions []ListAction
+	Preview func(selected string) string
+}
+
+func (r CommandResultPagedList) pageSize() int {
+	if r.PageSize > 0 {
+		return r.PageSize
+	}
+	return defaultPageSize
+}
+
+// fetchAll returns the items of every page.
+func (r CommandResultPagedList) fetchAll() ([]ListItem, error) {
+	items := []ListItem{}
+	for {
+		page, err := r.Fetch(len(items), r.pageSize())
+		if err != nil {
+			return nil, err
+		}
+
+		items = append(items, page...)
+		if len(page) < r.pageSize() {
+			return items, nil
+		}
+	}
+}
+
+// CommandResultPagedTable is a CommandResultTable whose rows are fetched in
+// pages as the user scrolls.
+type CommandResultPagedTable struct {
+	Header []string
+
+	// Fetch returns up to limit rows starting at offset, without the header.
+	// A page shorter than limit is the last one.
+	Fetch func(offset int, limit int) ([][]string, error)
+
+	// Total is the number of rows if it is known, and is only used to show
+	// the progress.
+	Total int
+
+	// PageSize is the limit passed to Fetch, 100 by default.
+	PageSize int
+
+	OnSelect func(selected []string) interface{}
+	Actions  []TableAction
+	Preview  func(selected []string) string
+}
+
+func (r CommandResultPagedTable) pageSize() int {
+	if r.PageSize > 0 {
+		return r.PageSize
+	}
+	return defaultPageSize
+}
+
+// fetchAll returns the header followed by the rows of every page.
+func (r CommandResultPagedTable) fetchAll() ([][]string, error) {
+	rows := [][]string{r.Header}
+	for {
+		page, err := r.Fetch(len(rows)-1, r.pageSize())
+		if err != nil {
+			return nil, err
+		}
+
+		rows = append(rows, page...)
+		if len(page) < r.pageSize() {
+			return rows, nil
+		}
+	}
+}
+
+// pager tracks the pages loaded by a paged list or table. It is shared by
+// pointer so pages of a previous result can be told apart.
+type pager struct {
+	pageSize int
+	total    int
+	loaded   int
+	loading  bool
+	done     bool
+	err      error
+}
+
+func newPager(pageSize int, total int) *pager {
+	return &pager{
+		pageSize: pageSize,
+		total:    total,
+		loading:  true,
+	}
+}
+
+// needsPage returns true if the cursor is close enough to the last loaded
+// row to fetch the next page.
+func (p *pager) needsPage(cursor int) bool {
+	return !p.loading && !p.done && p.err == nil && cursor >= p.loaded-p.pageSize/2
+}
+
+// scrolled clears the error of the last fetch when a key is pressed, so the
+// page is fetched again as the user keeps scrolling.
+func (p *pager) scrolled(msg tea.Msg) {
+	if _, ok := msg.(tea.KeyMsg); ok {
+		p.err = nil
+	}
+}
+
+// pageLoaded records a page fetched in the background.
+func (p *pager) pageLoaded(rows int, err error) {
+	p.loading = false
+	p.err = err
+	if err != nil {
+		return
+	}
+
+	p.loaded += rows
+	p.done = rows < p.pageSize
+}
+
+func (p *pager) View() string {
+	switch {
+	case p.err != nil:
+		return fmt.Sprintf("ERROR: %v", p.err)
+
+	case p.loading && p.total > 0:
+		return fmt.Sprintf("loading... (%d of %d)", p.loaded, p.total)
+
+	case p.loading:
+		return "loading..."
+	}
+
+	return ""
+}
+
+// withPagerView adds the loading indicator under the view, if any.
+func withPagerView(view string, p *pager) string {
+	if p == nil || p.View() == "" {
+		return view
+	}
+
+	return view + "\n" + p.View()
+}
+
+// listPageLoaded is sent when a page of a CommandResultPagedList is fetched.
+// The first page carries the result to build the list with.
+type listPageLoaded struct {
+	result CommandResultPagedList
+	pager  *pager
+	offset int
+	items  []ListItem
+	err    error
+}
+
+func fetchListPage(result CommandResultPagedList, p *pager, offset int) tea.Cmd {
+	return func() tea.Msg {
+		items, err := result.Fetch(offset, result.pageSize())
+		return listPageLoaded{
+			result: result,
+			pager:  p,
+			offset: offset,
+			items:  items,
+			err:    err,
+		}
+	}
+}
+
+// tablePageLoaded is sent when a page of a CommandResultPagedTable is
+// fetched.
+type tablePageLoaded struct {
+	result CommandResultPagedTable
+	pager  *pager
+	offset int
+	rows   [][]string
+	err    error
+}
+
+func fetchTablePage(result CommandResultPagedTable, p *pager, offset int) tea.Cmd {
+	return func() tea.Msg {
+		rows, err := result.Fetch(offset, result.pageSize())
+		return tablePageLoaded{
+			result: result,
+			pager:  p,
+			offset: offset,
+			rows:   rows,
+			err:    err,
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package vorl
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPagerNeedsPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		pager  pager
+		cursor int
+		want   bool
+	}{
+		{"far from the end", pager{pageSize: 10, loaded: 10}, 2, false},
+		{"close to the end", pager{pageSize: 10, loaded: 10}, 5, true},
+		{"loading", pager{pageSize: 10, loaded: 10, loading: true}, 9, false},
+		{"all loaded", pager{pageSize: 10, loaded: 10, done: true}, 9, false},
+		{"failed", pager{pageSize: 10, loaded: 10, err: errors.New("failed")}, 9, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.pager.needsPage(tt.cursor); got != tt.want {
+				t.Errorf("needsPage(%d) = %v, want %v", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPagerPageLoaded(t *testing.T) {
+	p := newPager(10, 0)
+	p.pageLoaded(10, nil)
+	if p.loading || p.done || p.loaded != 10 {
+		t.Errorf("after a full page: loading %v, done %v, loaded %d", p.loading, p.done, p.loaded)
+	}
+
+	p.pageLoaded(3, nil)
+	if !p.done || p.loaded != 13 {
+		t.Errorf("after a short page: done %v, loaded %d", p.done, p.loaded)
+	}
+}
+
+// pagedTestTable returns a table with the rows of a first page, and the
+// offsets of the pages it fetches.
+func pagedTestTable(pageSize int) (replTable, *pager, *[]int) {
+	rows := [][]string{{"n"}}
+	for i := 0; i < pageSize; i++ {
+		rows = append(rows, []string{fmt.Sprint(i)})
+	}
+
+	offsets := []int{}
+	p := newPager(pageSize, 0)
+	p.pageLoaded(pageSize, nil)
+
+	table := newTable(rows, nil, nil, 80, 30, DefaultKeyMap())
+	table.setPager(p, func(offset int) tea.Cmd {
+		offsets = append(offsets, offset)
+		return nil
+	})
+	table.SetInteractiveMode(true)
+
+	return table, p, &offsets
+}
+
+func TestPagedTableFetchesNextPage(t *testing.T) {
+	table, p, offsets := pagedTestTable(4)
+
+	table, _ = table.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if len(*offsets) != 0 {
+		t.Fatalf("fetched %v far from the end", *offsets)
+	}
+
+	table, _ = table.Update(tea.KeyMsg{Type: tea.KeyDown})
+	table, _ = table.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if !slices.Equal(*offsets, []int{4}) {
+		t.Fatalf("fetched %v, want [4]", *offsets)
+	}
+	if !p.loading {
+		t.Error("the pager is not loading the next page")
+	}
+
+	table = table.appendPage([][]string{{"4"}, {"5"}})
+	p.pageLoaded(2, nil)
+	table.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if len(*offsets) != 1 || !p.done {
+		t.Errorf("fetched %v after the last page", *offsets)
+	}
+}
+
+func TestPagedTableRetriesFailedPage(t *testing.T) {
+	table, p, offsets := pagedTestTable(4)
+	table.table.SetCursor(3)
+
+	p.loading = true
+	p.pageLoaded(0, errors.New("failed"))
+
+	table, _ = table.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	if len(*offsets) != 0 {
+		t.Fatalf("fetched %v without a key pressed", *offsets)
+	}
+
+	table.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if !slices.Equal(*offsets, []int{4}) {
+		t.Errorf("fetched %v after a key was pressed, want [4]", *offsets)
+	}
+}
+
+func TestPagedListFetchAll(t *testing.T) {
+	result := CommandResultPagedList{
+		PageSize: 2,
+		Fetch: func(offset int, limit int) ([]ListItem, error) {
+			items := []ListItem{}
+			for i := offset; i < min(offset+limit, 5); i++ {
+				items = append(items, ListItem{Title: fmt.Sprint(i)})
+			}
+			return items, nil
+		},
+	}
+
+	items, err := result.fetchAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	titles := []string{}
+	for _, item := range items {
+		titles = append(titles, item.Title)
+	}
+	if want := []string{"0", "1", "2", "3", "4"}; !slices.Equal(titles, want) {
+		t.Errorf("fetched %q, want %q", titles, want)
+	}
+}
@@ -0,0 +1,107 @@
+package vorl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// PasteMode defines what happens when several lines are pasted in the
+// prompt.
+type PasteMode int
+
+const (
+	// PasteModeInsert inserts the pasted text in the prompt without running
+	// it. Since the prompt has a single line, new lines become spaces.
+	PasteModeInsert PasteMode = iota
+
+	// PasteModeQueue runs every pasted line as a command, one after the
+	// other, stopping at the first one that fails.
+	PasteModeQueue
+)
+
+// pastedCommands returns the non empty lines of a pasted text.
+func pastedCommands(text string) []string {
+	commands := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		command := strings.TrimSpace(line)
+		if command == "" {
+			continue
+		}
+		commands = append(commands, command)
+	}
+
+	return commands
+}
+
+// pasteUpdate handles a bracketed paste. It returns false if the paste has
+// to be inserted in the prompt.
+func (m model) pasteUpdate(msg tea.KeyMsg) (model, bool) {
+	if m.pasteMode != PasteModeQueue || !strings.ContainsAny(string(msg.Runes), "\r\n") {
+		return m, false
+	}
+
+	text := strings.ReplaceAll(string(msg.Runes), "\r", "\n")
+	commands := pastedCommands(text)
+	if len(commands) == 0 {
+		return m, true
+	}
+
+	if m.pasteConfirmAbove >= 0 && len(commands) > m.pasteConfirmAbove {
+		m.pendingPaste = commands
+		m.stateBeforePaste = m.state
+		m.state = replStateConfirmingPaste
+		return m, true
+	}
+
+	m.pasteQueue = append(m.pasteQueue, commands...)
+	m.pasteContinued = false
+	return m, true
+}
+
+// confirmPasteUpdate queues the pending pasted commands if the user presses
+// the Confirm keys, and discards them otherwise.
+func (m model) confirmPasteUpdate(msg tea.KeyMsg) model {
+	if key.Matches(msg, m.keyMap.Confirm) {
+		m.pasteQueue = append(m.pasteQueue, m.pendingPaste...)
+		m.pasteContinued = false
+	}
+
+	m.pendingPaste = nil
+	m.state = m.stateBeforePaste
+
+	return m
+}
+
+func (m model) confirmPasteView() string {
+	return fmt.Sprintf("run %d pasted commands? [%s/N]", len(m.pendingPaste), m.keyMap.Confirm.Keys()[0])
+}
+
+// runQueuedCommand runs the next pasted command if the REPL is waiting for
+// input.
+func (m model) runQueuedCommand() (model, tea.Cmd) {
+	if len(m.pasteQueue) == 0 {
+		return m, nil
+	}
+
+	switch m.state {
+	case replStateReadingInput,
+		replStateReadingInputAndList,
+		replStateReadingInputAndTable,
+		replStateReadingInputAndTree:
+
+	default:
+		return m, nil
+	}
+
+	command := m.pasteQueue[0]
+	m.pasteQueue = m.pasteQueue[1:]
+
+	// only the first command of the block is shown after the main prompt
+	continued := m.pasteContinued
+	m.pasteContinued = len(m.pasteQueue) > 0
+
+	return m.execInput(command, continued)
+}
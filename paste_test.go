@@ -0,0 +1,102 @@
+package vorl
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type testInterpreter struct{}
+
+func (testInterpreter) Exec(string) (interface{}, error) { return nil, nil }
+func (testInterpreter) Suggest(string) []string          { return nil }
+
+func paste(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text), Paste: true}
+}
+
+func newPasteModel(t *testing.T, mode PasteMode, confirmAbove int) model {
+	t.Helper()
+
+	r, err := NewREPL(testInterpreter{}, ">", "", WithPasteMode(mode, confirmAbove))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return r.model
+}
+
+func TestPastedCommands(t *testing.T) {
+	got := pastedCommands("ls\n\n  cd /tmp  \n\npwd")
+	if want := []string{"ls", "cd /tmp", "pwd"}; !slices.Equal(got, want) {
+		t.Errorf("pastedCommands = %q, want %q", got, want)
+	}
+}
+
+func TestPasteQueueRunsCommandsInOrder(t *testing.T) {
+	m := newPasteModel(t, PasteModeQueue, -1)
+
+	newModel, _ := m.Update(paste("ls\r\npwd\nwhoami\n"))
+	m = newModel.(model)
+	if m.state != replStateExecutingCommand {
+		t.Fatalf("the first pasted command is not running")
+	}
+	if want := []string{"pwd", "whoami"}; !slices.Equal(m.pasteQueue, want) {
+		t.Errorf("queue = %q, want %q", m.pasteQueue, want)
+	}
+
+	newModel, _ = m.Update(commandFinished{result: CommandResultSimple("done")})
+	newModel, _ = newModel.Update(CommandResultSimple("done"))
+	m = newModel.(model)
+	if want := []string{"whoami"}; !slices.Equal(m.pasteQueue, want) {
+		t.Errorf("queue after the first command = %q, want %q", m.pasteQueue, want)
+	}
+
+	// a failure drops the rest of the commands
+	newModel, _ = m.Update(commandFinished{result: commandError(errors.New("failed"))})
+	if queue := newModel.(model).pasteQueue; len(queue) != 0 {
+		t.Errorf("queue after a failure = %q, want none", queue)
+	}
+}
+
+func TestPasteConfirmation(t *testing.T) {
+	m := newPasteModel(t, PasteModeQueue, 1)
+
+	newModel, _ := m.Update(paste("ls\npwd"))
+	m = newModel.(model)
+	if m.state != replStateConfirmingPaste {
+		t.Fatalf("the pasted commands were not confirmed")
+	}
+
+	newModel, _ = m.Update(runes("n"))
+	m = newModel.(model)
+	if m.state != replStateReadingInput || len(m.pasteQueue) != 0 {
+		t.Errorf("the pasted commands were queued after they were rejected")
+	}
+
+	newModel, _ = m.Update(paste("ls\npwd"))
+	newModel, _ = newModel.Update(runes("y"))
+	m = newModel.(model)
+	if m.state != replStateExecutingCommand || !slices.Equal(m.pasteQueue, []string{"pwd"}) {
+		t.Errorf("the pasted commands did not run after they were confirmed")
+	}
+
+	// a single command is run without asking
+	m = newPasteModel(t, PasteModeQueue, 1)
+	newModel, _ = m.Update(paste("ls\n"))
+	if newModel.(model).state != replStateExecutingCommand {
+		t.Errorf("a single pasted command was not run")
+	}
+}
+
+func TestPasteInsertMode(t *testing.T) {
+	m := newPasteModel(t, PasteModeInsert, -1)
+
+	newModel, _ := m.Update(paste("ls\npwd"))
+	m = newModel.(model)
+	if m.state != replStateReadingInput || len(m.pasteQueue) != 0 {
+		t.Errorf("the pasted commands were run in insert mode")
+	}
+}
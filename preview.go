@@ -0,0 +1,137 @@
+package vorl
+
+import (
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// previewHeight is the maximum number of lines of a preview.
+const previewHeight = 10
+
+// previewDelay is how long the cursor has to stay on an item before its
+// preview is built, so scrolling fast does not build every preview.
+const previewDelay = 150 * time.Millisecond
+
+// previewPane shows the preview of the item under the cursor of an
+// interactive list or table. Previews are built in the background and
+// cached by item. It is shared by pointer so previews of a previous result
+// can be told apart.
+type previewPane struct {
+	cache map[string]string
+
+	// key identifies the item under the cursor, if valid is true
+	key     string
+	valid   bool
+	content string
+	loading bool
+
+	// seq changes every time the cursor moves, to drop outdated requests
+	seq int
+}
+
+type previewRequested struct {
+	pane *previewPane
+	seq  int
+	key  string
+	run  func() string
+}
+
+type previewLoaded struct {
+	pane    *previewPane
+	key     string
+	content string
+}
+
+func newPreviewPane() *previewPane {
+	return &previewPane{
+		cache: map[string]string{},
+	}
+}
+
+// update is called with the item under the cursor after every message. The
+// preview is built after previewDelay if the cursor is still on the item.
+func (p *previewPane) update(key string, run func() string) tea.Cmd {
+	if p.valid && key == p.key {
+		return nil
+	}
+
+	p.key = key
+	p.valid = true
+	p.seq++
+
+	if content, ok := p.cache[key]; ok {
+		p.content = content
+		p.loading = false
+		return nil
+	}
+
+	p.loading = true
+	seq := p.seq
+	return tea.Tick(previewDelay, func(time.Time) tea.Msg {
+		return previewRequested{pane: p, seq: seq, key: key, run: run}
+	})
+}
+
+// clear empties the pane when there is no item under the cursor.
+func (p *previewPane) clear() {
+	p.valid = false
+	p.seq++
+	p.content = ""
+	p.loading = false
+}
+
+// reset drops the cached previews when the items they were built for are
+// replaced.
+func (p *previewPane) reset() {
+	p.cache = map[string]string{}
+	p.clear()
+}
+
+// handle builds the requested previews and stores them once built.
+func (p *previewPane) handle(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case previewRequested:
+		if msg.pane != p || msg.seq != p.seq {
+			return nil
+		}
+
+		run, key := msg.run, msg.key
+		return func() tea.Msg {
+			return previewLoaded{pane: p, key: key, content: run()}
+		}
+
+	case previewLoaded:
+		if msg.pane != p {
+			return nil
+		}
+
+		p.cache[msg.key] = msg.content
+		if p.valid && msg.key == p.key {
+			p.content = msg.content
+			p.loading = false
+		}
+	}
+
+	return nil
+}
+
+func (p *previewPane) View(width int) string {
+	content := p.content
+	if p.loading {
+		content = "loading preview..."
+	}
+
+	separator := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Render(strings.Repeat("─", width))
+
+	body := lipgloss.NewStyle().
+		Width(width).
+		MaxHeight(previewHeight).
+		Render(content)
+
+	return separator + "\n" + body
+}
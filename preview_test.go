@@ -0,0 +1,76 @@
+package vorl
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// loadPreview builds the preview requested for the key, as if the cursor
+// stayed on its item.
+func loadPreview(p *previewPane, key string, run func() string) tea.Msg {
+	msg := previewRequested{pane: p, seq: p.seq, key: key, run: run}
+	return p.handle(msg)()
+}
+
+func TestPreviewDropsOutdatedRequests(t *testing.T) {
+	p := newPreviewPane()
+	run := func() string { return "preview" }
+
+	if cmd := p.update("a", run); cmd == nil || !p.loading {
+		t.Fatal("the preview was not requested")
+	}
+	outdated := previewRequested{pane: p, seq: p.seq, key: "a", run: run}
+
+	// the cursor moved before the delay
+	p.update("b", run)
+	if cmd := p.handle(outdated); cmd != nil {
+		t.Error("the preview of an item left was built")
+	}
+
+	// requests of another pane are dropped too
+	other := newPreviewPane()
+	other.update("b", run)
+	if cmd := p.handle(previewRequested{pane: other, seq: p.seq, key: "b", run: run}); cmd != nil {
+		t.Error("the preview requested by another pane was built")
+	}
+
+	if cmd := p.update("b", run); cmd != nil {
+		t.Error("the preview was requested again while the cursor did not move")
+	}
+}
+
+func TestPreviewCache(t *testing.T) {
+	p := newPreviewPane()
+	calls := 0
+	run := func() string {
+		calls++
+		return "preview of a"
+	}
+
+	p.update("a", run)
+	p.handle(loadPreview(p, "a", run))
+	if p.loading || p.content != "preview of a" {
+		t.Fatalf("content = %q, loading %v", p.content, p.loading)
+	}
+
+	p.update("b", func() string { return "preview of b" })
+	if cmd := p.update("a", run); cmd != nil || p.content != "preview of a" {
+		t.Errorf("the cached preview was not shown")
+	}
+	if calls != 1 {
+		t.Errorf("the preview was built %d times, want 1", calls)
+	}
+
+	// a preview loaded after the cursor moved is only cached
+	p.update("b", run)
+	p.handle(previewLoaded{pane: p, key: "a", content: "new preview of a"})
+	if !p.loading {
+		t.Error("the preview of another item was shown")
+	}
+
+	p.reset()
+	if cmd := p.update("a", run); cmd == nil {
+		t.Error("the cache was kept after the items were replaced")
+	}
+}
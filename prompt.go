@@ -0,0 +1,32 @@
+package vorl
+
+import "time"
+
+// PromptInfo describes the state of the REPL when the prompt is built.
+type PromptInfo struct {
+	// CommandsRun is the number of commands run since the REPL started.
+	CommandsRun int
+
+	// LastFailed is true if the last command returned an error.
+	LastFailed bool
+
+	// LastDuration is how long the last command took to run.
+	LastDuration time.Duration
+
+	Now time.Time
+}
+
+// Prompt is the text shown before the input. Both strings can be styled
+// with lipgloss.
+type Prompt struct {
+	Main string
+
+	// Continuation is shown instead of Main before the commands that follow
+	// the first one of a pasted block. Defaults to Main.
+	Continuation string
+}
+
+// PromptFunc builds the prompt. It is called when the REPL starts, after
+// every command finishes, after an item of an interactive result is
+// selected and when going back to a previous result.
+type PromptFunc func(info PromptInfo) Prompt
@@ -0,0 +1,36 @@
+package vorl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptRefreshedAfterSelection(t *testing.T) {
+	context := "dev"
+	r, err := NewREPL(validatingInterpreter{}, ">", "",
+		WithPromptFunc(func(PromptInfo) Prompt { return Prompt{Main: context + " >"} }),
+		WithStatusBar(func(PromptInfo) string { return "context: " + context }, StatusBarBottom),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	context = "prod"
+	newModel, _ := r.model.Update(resultSelected{result: CommandResultSimple("")})
+	m := newModel.(model)
+
+	if !strings.HasPrefix(m.textInput.textInput.Prompt, "prod >") {
+		t.Errorf("prompt = %q after a selection", m.textInput.textInput.Prompt)
+	}
+	if m.statusBar != "context: prod" {
+		t.Errorf("status bar = %q after a selection", m.statusBar)
+	}
+
+	context = "test"
+	m.resultStack = []resultFrame{{}}
+	m, _ = m.back()
+
+	if !strings.HasPrefix(m.textInput.textInput.Prompt, "test >") {
+		t.Errorf("prompt = %q after going back", m.textInput.textInput.Prompt)
+	}
+}
@@ -0,0 +1,102 @@
+package vorl
+
+import (
+	"regexp"
+	"strings"
+)
+
+const defaultRedactionReplacement = "****"
+
+// RedactionRule describes a part of a command that must not be stored in the
+// history.
+type RedactionRule struct {
+	Pattern *regexp.Regexp
+
+	// Replacement is used instead of the matched text. It can refer to
+	// submatches using the syntax of regexp.Regexp.Expand. Defaults to "****".
+	Replacement string
+
+	// Drop discards the whole command instead of masking the matched text.
+	Drop bool
+}
+
+// SensitiveCommandChecker can be implemented by an Interpreter to keep
+// commands out of the history.
+type SensitiveCommandChecker interface {
+	IsSensitive(command string) bool
+}
+
+// replacementReference matches the submatches a replacement refers to.
+var replacementReference = regexp.MustCompile(`\$(\w+|\{\w+\})`)
+
+// mayBeRedacted returns true if a rule matches the command, or the command
+// contains the text a rule replaces with, so it may have been redacted
+// before it was stored.
+func mayBeRedacted(command string, rules []RedactionRule) bool {
+	for _, rule := range rules {
+		if rule.Pattern == nil {
+			continue
+		}
+
+		if rule.Pattern.MatchString(command) {
+			return true
+		}
+		if !rule.Drop && containsReplacement(command, rule.Replacement) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsReplacement returns true if the command contains all the text of
+// the replacement, besides the submatches it refers to.
+func containsReplacement(command, replacement string) bool {
+	if replacement == "" {
+		replacement = defaultRedactionReplacement
+	}
+	replacement = strings.ReplaceAll(replacement, "$$", "$")
+
+	found := false
+	for _, part := range replacementReference.Split(replacement, -1) {
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(command, part) {
+			return false
+		}
+		found = true
+	}
+
+	return found
+}
+
+// redactCommand returns the command as it has to be stored in the history,
+// and false if it must not be stored at all.
+func redactCommand(
+	command string,
+	interpreter Interpreter,
+	rules []RedactionRule,
+) (string, bool) {
+	if checker, ok := interpreter.(SensitiveCommandChecker); ok && checker.IsSensitive(command) {
+		return "", false
+	}
+
+	for _, rule := range rules {
+		if rule.Pattern == nil || !rule.Pattern.MatchString(command) {
+			continue
+		}
+
+		if rule.Drop {
+			return "", false
+		}
+
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = defaultRedactionReplacement
+		}
+		command = rule.Pattern.ReplaceAllString(command, replacement)
+	}
+
+	return command, true
+}
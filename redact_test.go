@@ -0,0 +1,104 @@
+package vorl
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+type sensitiveInterpreter struct{}
+
+func (sensitiveInterpreter) Exec(string) (interface{}, error) { return nil, nil }
+func (sensitiveInterpreter) Suggest(string) []string          { return nil }
+
+func (sensitiveInterpreter) IsSensitive(command string) bool {
+	return strings.HasPrefix(command, "secret")
+}
+
+func TestRedactCommand(t *testing.T) {
+	rules := []RedactionRule{
+		{Pattern: regexp.MustCompile(`--password \S+`)},
+		{Pattern: regexp.MustCompile(`token=(\w)\w*`), Replacement: "token=${1}***"},
+		{Pattern: regexp.MustCompile(`^login`), Drop: true},
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		stored  bool
+	}{
+		{"no match", "ls -l", "ls -l", true},
+		{"default replacement", "connect --password hunter2", "connect ****", true},
+		{"replacement with submatch", "get token=abcdef", "get token=a***", true},
+		{"several rules", "get token=xyz --password p", "get token=x*** ****", true},
+		{"dropped by rule", "login user pass", "", false},
+		{"dropped by interpreter", "secret stuff", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, stored := redactCommand(tt.command, sensitiveInterpreter{}, rules)
+			if got != tt.want || stored != tt.stored {
+				t.Errorf("redactCommand(%q) = %q, %v, want %q, %v",
+					tt.command, got, stored, tt.want, tt.stored)
+			}
+		})
+	}
+}
+
+func TestHistoryResultRunsCommandAsTyped(t *testing.T) {
+	entries := []historyEntry{
+		{command: "ls", run: "ls"},
+		{command: "connect ****", run: "connect --password hunter2"},
+		{command: "connect ****"},
+	}
+
+	table, ok := historyResult(entries, nil).(CommandResultTable)
+	if !ok {
+		t.Fatalf("historyResult did not return a table")
+	}
+
+	tests := []struct {
+		row  int
+		want interface{}
+	}{
+		{1, execCommand("ls")},
+		{2, execCommand("connect --password hunter2")},
+		{3, CommandResultSimple("the command was redacted and cannot be run again")},
+	}
+
+	for _, tt := range tests {
+		if got := table.OnSelect(table.Table[tt.row]); got != tt.want {
+			t.Errorf("selecting row %d = %#v, want %#v", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestLoadedRedactedCommandsAreNotRun(t *testing.T) {
+	historyFile := filepath.Join(t.TempDir(), "history")
+	err := os.WriteFile(historyFile, []byte("ls\nconnect ****\nget token=a***\nlogin user\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewREPL(sensitiveInterpreter{}, ">", historyFile,
+		WithRedactionRules(
+			RedactionRule{Pattern: regexp.MustCompile(`--password \S+`)},
+			RedactionRule{Pattern: regexp.MustCompile(`token=(\w)\w*`), Replacement: "token=${1}***"},
+			RedactionRule{Pattern: regexp.MustCompile(`^drop`), Drop: true},
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"ls", "", "", "login user"}
+	for i, entry := range r.model.history {
+		if entry.run != want[i] {
+			t.Errorf("loaded %q to run %q, want %q", entry.command, entry.run, want[i])
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package vorl
+
+import (
+	"github.com/charmbracelet/lipgloss"
+)
+
+// StatusBarPosition is where the status bar is shown.
+type StatusBarPosition int
+
+const (
+	StatusBarBottom StatusBarPosition = iota
+	StatusBarTop
+)
+
+// StatusBarFunc returns the content of the status bar. It is called every
+// time the prompt is built, see PromptFunc, and when REPL.RefreshStatusBar
+// is called. It receives the same information as a PromptFunc.
+type StatusBarFunc func(info PromptInfo) string
+
+// refreshStatusBar is sent by REPL.RefreshStatusBar.
+type refreshStatusBar struct{}
+
+var statusBarStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("252")).
+	Background(lipgloss.Color("236")).
+	Padding(0, 1)
+
+func (m model) statusBarView() string {
+	return statusBarStyle.Width(m.width).Render(m.statusBar)
+}
+
+// withStatusBar adds the status bar to the view, if there is one.
+func (m model) withStatusBar(view string) string {
+	if m.statusBarFunc == nil {
+		return view
+	}
+
+	if m.statusBarPosition == StatusBarTop {
+		return m.statusBarView() + "\n" + view
+	}
+
+	return view + m.statusBarView() + "\n"
+}
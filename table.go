@@ -1,7 +1,14 @@
 package vorl
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -11,13 +18,55 @@ type replTable struct {
 	interactiveMode bool
 	execFn          func([]string) interface{}
 	executedCommand bool
+	keyMap          KeyMap
+	help            help.Model
+
+	actions        []TableAction
+	actionBindings []key.Binding
+	pendingAction  *pendingAction
+
+	// height is the maximum height of the table
+	height int
+
+	// pager is set when the rows are fetched in pages
+	pager     *pager
+	fetchPage func(offset int) tea.Cmd
+
+	// live is set when the interpreter sends new rows, and keyColumn
+	// identifies them
+	live      *liveSubscription
+	keyColumn int
+
+	// styles are the styles last set on the table
+	styles table.Styles
+
+	width     int
+	preview   *previewPane
+	previewFn func([]string) string
+
+	// rows and columns are kept as given, and shown in the order of sortKeys
+	rows     []table.Row
+	columns  []table.Column
+	sortKeys []sortKey
+
+	// filter is the query typed after the Filter or Search keys. Filters
+	// only show the rows that match it, searches highlight them.
+	filter        textinput.Model
+	settingFilter bool
+	searching     bool
+
+	// saveInput is the file typed after the SaveRows keys
+	saveInput textinput.Model
+	saving    bool
 }
 
 func newTable(
 	rows [][]string,
 	execFn func([]string) interface{},
+	actions []TableAction,
 	width int,
 	height int,
+	keyMap KeyMap,
 ) replTable {
 	equalColSize := width/len(rows[0]) - 2
 
@@ -78,14 +127,87 @@ func newTable(
 
 	t.SetStyles(s)
 
+	t.KeyMap.LineUp = keyMap.LineUp
+	t.KeyMap.LineDown = keyMap.LineDown
+	t.KeyMap.PageUp = keyMap.PageUp
+	t.KeyMap.PageDown = keyMap.PageDown
+	t.KeyMap.HalfPageUp = keyMap.HalfPageUp
+	t.KeyMap.HalfPageDown = keyMap.HalfPageDown
+	t.KeyMap.GotoTop = keyMap.GotoTop
+	t.KeyMap.GotoBottom = keyMap.GotoBottom
+
+	actionBindings := make([]key.Binding, len(actions))
+	for i, action := range actions {
+		actionBindings[i] = actionBinding(action.Key, action.Name)
+	}
+
+	filter := textinput.New()
+	filter.Prompt = "filter: "
+
+	saveInput := textinput.New()
+	saveInput.Prompt = "save to: "
+
 	return replTable{
-		table:  t,
-		execFn: execFn,
+		table:          t,
+		styles:         s,
+		execFn:         execFn,
+		keyMap:         keyMap,
+		help:           help.New(),
+		actions:        actions,
+		actionBindings: actionBindings,
+		height:         height - 6,
+		width:          width,
+		rows:           tableRows,
+		columns:        tableColumns,
+		filter:         filter,
+		saveInput:      saveInput,
 	}
 }
 
 func (rt replTable) View() string {
-	return rt.table.View()
+	if !rt.interactiveMode {
+		return withPagerView(rt.table.View(), rt.pager)
+	}
+
+	view := withPagerView(rt.highlightMatches(rt.table.View()), rt.pager)
+
+	if rt.Filtered() {
+		view += "\n" + rt.filterView()
+	}
+
+	if rt.preview != nil {
+		view += "\n" + rt.preview.View(rt.width)
+	}
+
+	if rt.pendingAction != nil {
+		return view + "\n" + rt.pendingAction.View(rt.keyMap.Confirm)
+	}
+
+	if rt.saving {
+		view += "\n" + rt.saveInput.View()
+	}
+
+	return view + "\n" + rt.help.ShortHelpView(rt.helpKeys())
+}
+
+func (rt replTable) helpKeys() []key.Binding {
+	if rt.settingFilter || rt.saving {
+		return []key.Binding{rt.keyMap.AcceptFilter, rt.keyMap.ClearFilter}
+	}
+
+	keys := []key.Binding{rt.keyMap.LineUp, rt.keyMap.LineDown}
+	keys = append(keys, rt.actionBindings...)
+	keys = append(keys, rt.keyMap.SortByColumn)
+	if len(rt.sortKeys) > 0 {
+		keys = append(keys, rt.keyMap.ClearSort)
+	}
+	keys = append(keys, rt.keyMap.CopyRows, rt.keyMap.SaveRows)
+	if rt.filter.Value() != "" {
+		keys = append(keys, rt.keyMap.NextMatch, rt.keyMap.PreviousMatch, rt.keyMap.ClearFilter)
+	} else {
+		keys = append(keys, rt.keyMap.Filter, rt.keyMap.Search)
+	}
+	return append(keys, rt.keyMap.interactionHelpKeys()...)
 }
 
 func (rt replTable) Update(msg tea.Msg) (replTable, tea.Cmd) {
@@ -102,7 +224,7 @@ func (rt replTable) Update(msg tea.Msg) (replTable, tea.Cmd) {
 		s.Selected = s.Cell.Copy()
 		s.Selected.Padding(0)
 		s.Selected.Margin(0)
-		rt.table.SetStyles(s)
+		rt.setStyles(s)
 		return rt, nil
 	}
 
@@ -110,18 +232,90 @@ func (rt replTable) Update(msg tea.Msg) (replTable, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEnter:
-			if rt.execFn != nil && len(rt.table.Rows()) > 0 {
+		if rt.pendingAction != nil {
+			if key.Matches(msg, rt.keyMap.Confirm) {
+				cmds = append(cmds, selectCmd(rt.table.View(), rt.pendingAction.label, rt.pendingAction.run))
+				rt.executedCommand = true
+			}
+			rt.pendingAction = nil
+			return rt, tea.Batch(cmds...)
+		}
+
+		if rt.saving {
+			return rt.saveUpdate(msg)
+		}
+
+		if rt.settingFilter {
+			var cmd tea.Cmd
+			rt, cmd = rt.filterUpdate(msg)
+			return rt, tea.Batch(cmd, rt.nextPage(msg), rt.updatePreview(msg))
+		}
+
+		if len(rt.table.Rows()) > 0 {
+			for i, binding := range rt.actionBindings {
+				if !key.Matches(msg, binding) {
+					continue
+				}
+
+				action := rt.actions[i]
 				row := rt.table.SelectedRow()
-				cmds = append(cmds, tea.Println(rt.table.View()))
-				cmds = append(cmds, func() tea.Msg {
-					msg := rt.execFn(row)
-					if msg == nil {
-						msg = CommandResultSimple("")
+				run := func() interface{} {
+					return action.Run(row)
+				}
+
+				if action.Confirm {
+					rt.pendingAction = &pendingAction{
+						name:  fmt.Sprintf("%s on %s", action.Name, strings.Join(row, " ")),
+						label: rowLabel(row),
+						run:   run,
 					}
-					return msg
-				})
+				} else {
+					cmds = append(cmds, selectCmd(rt.table.View(), rowLabel(row), run))
+					rt.executedCommand = true
+				}
+
+				return rt, tea.Batch(cmds...)
+			}
+		}
+
+		switch {
+		case key.Matches(msg, rt.keyMap.SortByColumn):
+			// the n-th key of the binding sorts by the n-th column
+			column := slices.Index(rt.keyMap.SortByColumn.Keys(), msg.String())
+			if column >= 0 && column < len(rt.columns) {
+				rt.sortBy(column)
+			}
+
+		case key.Matches(msg, rt.keyMap.ClearSort):
+			rt.clearSort()
+
+		case key.Matches(msg, rt.keyMap.CopyRows):
+			cmds = append(cmds, copyRows(rt.shownRows()))
+
+		case key.Matches(msg, rt.keyMap.SaveRows):
+			cmds = append(cmds, rt.startSave())
+
+		case key.Matches(msg, rt.keyMap.Filter):
+			cmds = append(cmds, rt.startFilter(false))
+
+		case key.Matches(msg, rt.keyMap.Search):
+			cmds = append(cmds, rt.startFilter(true))
+
+		case key.Matches(msg, rt.keyMap.ClearFilter):
+			rt.clearFilter()
+
+		case key.Matches(msg, rt.keyMap.NextMatch):
+			rt.jumpToMatch(rt.table.Cursor()+1, 1)
+
+		case key.Matches(msg, rt.keyMap.PreviousMatch):
+			rt.jumpToMatch(rt.table.Cursor()-1, -1)
+
+		case key.Matches(msg, rt.keyMap.Select):
+			if rt.execFn != nil && len(rt.table.Rows()) > 0 {
+				row := rt.table.SelectedRow()
+				cmds = append(cmds, selectCmd(rt.table.View(), rowLabel(row), func() interface{} {
+					return rt.execFn(row)
+				}))
 				rt.executedCommand = true
 			}
 		}
@@ -131,17 +325,166 @@ func (rt replTable) Update(msg tea.Msg) (replTable, tea.Cmd) {
 		Foreground(lipgloss.Color("229")).
 		Background(lipgloss.Color("57")).
 		Bold(false)
-	rt.table.SetStyles(s)
+	rt.setStyles(s)
 
 	var cmd tea.Cmd
 	rt.table, cmd = rt.table.Update(msg)
 	cmds = append(cmds, cmd)
+	cmds = append(cmds, rt.nextPage(msg))
+	cmds = append(cmds, rt.updatePreview(msg))
 
 	return rt, tea.Batch(cmds...)
 }
 
+func (rt *replTable) setStyles(s table.Styles) {
+	rt.styles = s
+	rt.table.SetStyles(s)
+}
+
+// setPreview shows the preview of the row under the cursor in interactive
+// mode, under the table.
+func (rt *replTable) setPreview(fn func([]string) string) {
+	rt.preview = newPreviewPane()
+	rt.previewFn = fn
+	rt.height = max(rt.height-previewHeight-1, 1)
+	rt.table.SetHeight(min(len(rt.table.Rows()), rt.height))
+}
+
+func (rt *replTable) updatePreview(msg tea.Msg) tea.Cmd {
+	if rt.preview == nil {
+		return nil
+	}
+
+	cmd := rt.preview.handle(msg)
+
+	row := rt.table.SelectedRow()
+	if row == nil {
+		rt.preview.clear()
+		return cmd
+	}
+
+	fn := rt.previewFn
+	return tea.Batch(cmd, rt.preview.update(strings.Join(row, "\x1f"), func() string {
+		return fn(row)
+	}))
+}
+
+// setPager makes the table fetch the next page when the cursor gets close
+// to the last loaded row.
+func (rt *replTable) setPager(p *pager, fetchPage func(offset int) tea.Cmd) {
+	rt.pager = p
+	rt.fetchPage = fetchPage
+}
+
+// nextPage fetches the next page if it is needed, and again after it failed
+// once a key is pressed.
+func (rt *replTable) nextPage(msg tea.Msg) tea.Cmd {
+	if rt.pager == nil {
+		return nil
+	}
+
+	rt.pager.scrolled(msg)
+
+	// rows left out by the filter count as scrolled past
+	cursor := rt.table.Cursor() + len(rt.rows) - len(rt.table.Rows())
+	if !rt.pager.needsPage(cursor) {
+		return nil
+	}
+
+	rt.pager.loading = true
+	return rt.fetchPage(rt.pager.loaded)
+}
+
+// appendPage adds the rows of a page fetched in the background.
+func (rt replTable) appendPage(rows [][]string) replTable {
+	rt.rows = append([]table.Row{}, rt.rows...)
+	for _, row := range rows {
+		rt.rows = append(rt.rows, row)
+	}
+
+	rt.refreshRows()
+
+	return rt
+}
+
+// replaceRows shows the rows sent by the interpreter, keeping the cursor on
+// the row with the same key.
+func (rt replTable) replaceRows(rows [][]string) (replTable, tea.Cmd) {
+	rt.rows = make([]table.Row, len(rows))
+	for i, row := range rows {
+		rt.rows[i] = row
+	}
+
+	current := rt.table.SelectedRow()
+	rt.setRows(func(row table.Row) bool {
+		return rt.keyColumn < len(current) && rt.keyColumn < len(row) &&
+			row[rt.keyColumn] == current[rt.keyColumn]
+	})
+
+	if rt.preview == nil {
+		return rt, nil
+	}
+
+	// rows with the same key may have changed, and so their previews
+	rt.preview.reset()
+	if !rt.interactiveMode {
+		return rt, nil
+	}
+
+	return rt, rt.updatePreview(nil)
+}
+
+// refreshRows shows the rows again after they, the sort keys or the filter
+// change, keeping the cursor on the same row.
+func (rt *replTable) refreshRows() {
+	current := rt.table.SelectedRow()
+	rt.setRows(func(row table.Row) bool {
+		return current != nil && slices.Equal(row, current)
+	})
+}
+
+// setRows shows the rows that match the filter in the order of the sort
+// keys, moving the cursor to the first row matched by isCursor.
+func (rt *replTable) setRows(isCursor func(table.Row) bool) {
+	rows := rt.filteredRows(rt.sortedRows())
+
+	// the cursor of an empty table is -1, and goes back to the first row
+	// when rows are shown again
+	cursor := max(min(rt.table.Cursor(), len(rows)-1), 0)
+	for i, row := range rows {
+		if isCursor(row) {
+			cursor = i
+			break
+		}
+	}
+
+	rt.table.SetColumns(rt.sortedColumns())
+	rt.table.SetRows(rows)
+	rt.table.SetHeight(min(len(rows), rt.height))
+	rt.table.SetCursor(cursor)
+}
+
+// rowLabel names a row in the breadcrumb of the results it drills down to.
+func rowLabel(row []string) string {
+	if len(row) == 0 {
+		return ""
+	}
+	return row[0]
+}
+
 func (rt *replTable) SetInteractiveMode(enabled bool) {
 	rt.interactiveMode = enabled
+	rt.pendingAction = nil
+	rt.settingFilter = false
+	rt.filter.Blur()
+	rt.saving = false
+	rt.saveInput.Blur()
+
+	// a preview requested before leaving is dropped, so it has to be
+	// requested again when coming back
+	if !enabled && rt.preview != nil {
+		rt.preview.clear()
+	}
 }
 
 func (rt replTable) ExecutedCommand() bool {
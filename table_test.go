@@ -0,0 +1,247 @@
+package vorl
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestCheckResult(t *testing.T) {
+	stopped := false
+
+	tests := []struct {
+		name   string
+		result interface{}
+		valid  bool
+	}{
+		{"table", CommandResultTable{Table: [][]string{{"a"}}}, true},
+		{"empty table", CommandResultTable{}, false},
+		{"table without columns", CommandResultTable{Table: [][]string{{}}}, false},
+		{"paged table", CommandResultPagedTable{Header: []string{"a"}}, true},
+		{"paged table without header", CommandResultPagedTable{}, false},
+		{"live table", CommandResultLiveTable{Table: [][]string{{"a"}}}, true},
+		{"empty live table", CommandResultLiveTable{Stop: func() { stopped = true }}, false},
+		{"other results", CommandResultSimple(""), true},
+		{"no result", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkResult(tt.result); (err == nil) != tt.valid {
+				t.Errorf("checkResult = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+
+	if !stopped {
+		t.Errorf("the empty live table was not stopped")
+	}
+}
+
+func TestSortedColumnsFitArrow(t *testing.T) {
+	table := newTable([][]string{{"name", "size"}, {"a", "1"}}, nil, nil, 80, 10, DefaultKeyMap())
+	table.columns[0].Width = 4
+	table.sortBy(0)
+
+	got := table.sortedColumns()[0].Title
+	if ansi.StringWidth(got) > 4 {
+		t.Errorf("title = %q, wider than its column", got)
+	}
+	if !strings.HasSuffix(got, "▲") {
+		t.Errorf("title = %q, the sort indicator was cut", got)
+	}
+}
+
+func TestSaveSortedTable(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModel, _ := r.model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	newModel, _ = newModel.Update(CommandResultTable{Table: [][]string{{"name"}, {"b"}, {"c"}, {"a"}}})
+	m, _ := newModel.(model).enterInteraction()
+	m.tableResult.sortBy(0)
+
+	file := filepath.Join(t.TempDir(), "rows.txt")
+	rt := *m.tableResult
+	var cmd tea.Cmd
+	for _, k := range []tea.KeyMsg{{Type: tea.KeyCtrlS}, runes(file), {Type: tea.KeyEnter}} {
+		rt, cmd = rt.Update(k)
+	}
+	if cmd == nil {
+		t.Fatal("saving the rows returned no command")
+	}
+
+	msg, ok := cmd().(CommandResultSaveTo)
+	if !ok {
+		t.Fatalf("saving the rows sent %T", msg)
+	}
+
+	newModel, cmd = m.Update(msg)
+	if newModel.(model).tableResult == nil {
+		t.Error("the table was dropped after saving its rows")
+	}
+	if cmd == nil {
+		t.Fatal("the rows were not saved")
+	}
+	if msg := cmd(); msg != nil {
+		t.Fatalf("saving the rows failed: %v", msg)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, line := range strings.Split(ansi.Strip(string(content)), "\n")[1:] {
+		if name := strings.TrimSpace(line); name != "" && !strings.HasPrefix(name, "─") {
+			names = append(names, name)
+		}
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(names, want) {
+		t.Errorf("saved %q, want %q", names, want)
+	}
+}
+
+func TestTypingSaveFileKeepsInteraction(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModel, _ := r.model.Update(tea.WindowSizeMsg{Width: 80, Height: 30})
+	newModel, _ = newModel.Update(CommandResultTable{Table: [][]string{{"name"}, {"a"}}})
+	m, _ := newModel.(model).enterInteraction()
+
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	newModel, _ = newModel.Update(runes("q"))
+
+	m = newModel.(model)
+	if m.state != replStateTableInteraction {
+		t.Fatalf("the table was left while typing the file")
+	}
+	if got := m.tableResult.saveInput.Value(); got != "q" {
+		t.Errorf("file = %q, want %q", got, "q")
+	}
+}
+
+func TestNaturalCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"file9", "file10", -1},
+		{"file10", "file9", 1},
+		{"file010", "file10", 0},
+		{"a", "b", -1},
+		{"a", "a1", -1},
+		{"", "a", -1},
+		{"", "", 0},
+		{"v1.10.2", "v1.9.12", 1},
+		{"x2y", "x2z", -1},
+	}
+
+	for _, tt := range tests {
+		if got := naturalCompare(tt.a, tt.b); got != tt.want {
+			t.Errorf("naturalCompare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestCompareCells(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"10", "9", 1},
+		{"-1.5", "0.2", -1},
+		{" 3 ", "3", 0},
+		{"1e3", "999", 1},
+		{"Apple", "apple", 0},
+		{"Banana", "apple", 1},
+		{"10", "abc", -1},
+		{"item2", "Item10", -1},
+	}
+
+	for _, tt := range tests {
+		if got := compareCells(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareCells(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSortedRows(t *testing.T) {
+	rows := [][]string{
+		{"name", "size"},
+		{"b", "10"},
+		{"a", "9"},
+		{"c", "10"},
+		{"a", "2"},
+	}
+
+	tests := []struct {
+		name string
+		keys []sortKey
+		want []string
+	}{
+		{"unsorted", nil, []string{"b", "a", "c", "a"}},
+		{"ascending", []sortKey{{column: 1}}, []string{"a2", "a9", "b10", "c10"}},
+		{"descending", []sortKey{{column: 1, descending: true}}, []string{"b10", "c10", "a9", "a2"}},
+		{"ties", []sortKey{{column: 1, descending: true}, {column: 0, descending: true}}, []string{"c10", "b10", "a9", "a2"}},
+		{"stable", []sortKey{{column: 0}}, []string{"a9", "a2", "b10", "c10"}},
+		{"missing column", []sortKey{{column: 5}}, []string{"b10", "a9", "c10", "a2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := newTable(rows, nil, nil, 80, 10, DefaultKeyMap())
+			table.sortKeys = tt.keys
+
+			got := []string{}
+			for _, row := range table.sortedRows() {
+				if tt.keys == nil {
+					got = append(got, row[0])
+				} else {
+					got = append(got, row[0]+row[1])
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sorted rows = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortBy(t *testing.T) {
+	table := newTable([][]string{{"name", "size"}, {"a", "1"}}, nil, nil, 80, 10, DefaultKeyMap())
+	table.columns[0].Width = 10
+	table.columns[1].Width = 10
+
+	table.sortBy(1)
+	table.sortBy(0)
+	table.sortBy(0)
+	want := []sortKey{{column: 0, descending: true}, {column: 1}}
+	if !slices.Equal(table.sortKeys, want) {
+		t.Errorf("sort keys = %v, want %v", table.sortKeys, want)
+	}
+
+	titles := []string{}
+	for _, c := range table.sortedColumns() {
+		titles = append(titles, c.Title)
+	}
+	if want := []string{"name ▼1", "size ▲2"}; !slices.Equal(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+
+	table.clearSort()
+	if table.sortKeys != nil {
+		t.Errorf("sort keys = %v after clearing them", table.sortKeys)
+	}
+}
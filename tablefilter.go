@@ -0,0 +1,298 @@
+package vorl
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// tableQuery is the text typed to filter or search a table. A query like
+// "col:value" only matches the cells of the column titled col.
+type tableQuery struct {
+	// column is -1 if the query matches every column
+	column int
+	value  string
+}
+
+func parseTableQuery(query string, columns []table.Column) tableQuery {
+	if name, value, ok := strings.Cut(query, ":"); ok {
+		for i, c := range columns {
+			if strings.EqualFold(strings.TrimSpace(name), c.Title) {
+				return tableQuery{column: i, value: value}
+			}
+		}
+	}
+
+	return tableQuery{column: -1, value: query}
+}
+
+func (q tableQuery) matchesRow(row table.Row) bool {
+	for i, cell := range row {
+		if (q.column < 0 || q.column == i) && matchCell(cell, q.value) != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchCell returns the positions of the runes of the cell matched by the
+// value, ignoring case. The value matches as a substring if it can, or else
+// as a fuzzy match of its runes in order. It returns nil if there is no
+// match.
+func matchCell(cell string, value string) []int {
+	c := []rune(cell)
+	v := []rune(value)
+	if len(v) == 0 {
+		return nil
+	}
+
+	for i := range c {
+		c[i] = unicode.ToLower(c[i])
+	}
+	for i := range v {
+		v[i] = unicode.ToLower(v[i])
+	}
+
+	for i := 0; i+len(v) <= len(c); i++ {
+		if slices.Equal(c[i:i+len(v)], v) {
+			matched := make([]int, len(v))
+			for j := range matched {
+				matched[j] = i + j
+			}
+			return matched
+		}
+	}
+
+	matched := []int{}
+	for i := 0; i < len(c) && len(matched) < len(v); i++ {
+		if c[i] == v[len(matched)] {
+			matched = append(matched, i)
+		}
+	}
+
+	if len(matched) < len(v) {
+		return nil
+	}
+
+	return matched
+}
+
+func (rt replTable) query() tableQuery {
+	return parseTableQuery(rt.filter.Value(), rt.columns)
+}
+
+// filteredRows leaves out the rows that do not match the filter. Searches
+// do not leave out any row.
+func (rt replTable) filteredRows(rows []table.Row) []table.Row {
+	q := rt.query()
+	if rt.searching || q.value == "" {
+		return rows
+	}
+
+	filtered := []table.Row{}
+	for _, row := range rows {
+		if q.matchesRow(row) {
+			filtered = append(filtered, row)
+		}
+	}
+
+	return filtered
+}
+
+// startFilter starts typing a filter, or a search if searching is true.
+func (rt *replTable) startFilter(searching bool) tea.Cmd {
+	rt.searching = searching
+	rt.settingFilter = true
+	rt.filter.Prompt = "filter: "
+	if searching {
+		rt.filter.Prompt = "search: "
+	}
+
+	rt.filter.SetValue("")
+	rt.refreshRows()
+
+	return rt.filter.Focus()
+}
+
+func (rt *replTable) clearFilter() {
+	rt.settingFilter = false
+	rt.filter.Blur()
+	rt.filter.SetValue("")
+	rt.refreshRows()
+}
+
+func (rt replTable) filterUpdate(msg tea.KeyMsg) (replTable, tea.Cmd) {
+	switch {
+	case key.Matches(msg, rt.keyMap.AcceptFilter):
+		rt.settingFilter = false
+		rt.filter.Blur()
+		return rt, nil
+
+	case key.Matches(msg, rt.keyMap.ClearFilter):
+		rt.clearFilter()
+		return rt, nil
+	}
+
+	var cmd tea.Cmd
+	rt.filter, cmd = rt.filter.Update(msg)
+
+	if rt.searching {
+		rt.jumpToMatch(rt.table.Cursor(), 1)
+	} else {
+		rt.refreshRows()
+	}
+
+	return rt, cmd
+}
+
+// jumpToMatch moves the cursor to the first row that matches the query,
+// starting at the row from and going in the given direction, wrapping
+// around at the ends of the table.
+func (rt *replTable) jumpToMatch(from int, direction int) {
+	rows := rt.table.Rows()
+	q := rt.query()
+	if len(rows) == 0 || q.value == "" {
+		return
+	}
+
+	for i := range rows {
+		j := ((from+i*direction)%len(rows) + len(rows)) % len(rows)
+		if q.matchesRow(rows[j]) {
+			rt.table.SetCursor(j)
+			return
+		}
+	}
+}
+
+// filterView shows the query and how many rows match it.
+func (rt replTable) filterView() string {
+	q := rt.query()
+	if q.value == "" {
+		return rt.filter.View()
+	}
+
+	matching := 0
+	for _, row := range rt.rows {
+		if q.matchesRow(row) {
+			matching++
+		}
+	}
+
+	status := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("240")).
+		Render(fmt.Sprintf("%d/%d rows", matching, len(rt.rows)))
+
+	return rt.filter.View() + "  " + status
+}
+
+// highlightMatches underlines the matches of the query in the rows of the
+// rendered table. The table cannot render styled cells, so the matches are
+// found again in the text of every cell shown.
+func (rt replTable) highlightMatches(view string) string {
+	q := rt.query()
+	if q.value == "" {
+		return view
+	}
+
+	// the row under the cursor is the only one that changes when rendered
+	// without the Selected style
+	unselected := rt.table
+	styles := rt.styles
+	styles.Selected = lipgloss.NewStyle()
+	unselected.SetStyles(styles)
+
+	lines := strings.Split(view, "\n")
+	plainLines := strings.Split(unselected.View(), "\n")
+	if len(lines) != len(plainLines) {
+		return view
+	}
+
+	cell := rt.styles.Cell.Copy().Padding(0).Margin(0).Inline(true)
+	selected := rt.styles.Selected.Copy().Padding(0).Margin(0).Inline(true)
+
+	// the first two lines are the titles and the border under them
+	for i := 2; i < len(lines); i++ {
+		base := cell
+		if lines[i] != plainLines[i] {
+			base = selected
+		}
+		lines[i] = rt.highlightLine(ansi.Strip(plainLines[i]), base, q)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// highlightLine renders a row of the table, shown as plain, underlining the
+// matches of the query in its cells. Cells are found by their display
+// columns, as wide runes take two of them.
+func (rt replTable) highlightLine(plain string, base lipgloss.Style, q tableQuery) string {
+	runes := []rune(plain)
+	highlighted := rt.matchedRunes(runes, q)
+	match := base.Copy().Underline(true)
+
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && highlighted[j] == highlighted[i] {
+			j++
+		}
+
+		style := base
+		if highlighted[i] {
+			style = match
+		}
+		b.WriteString(style.Render(string(runes[i:j])))
+
+		i = j
+	}
+
+	return b.String()
+}
+
+// matchedRunes returns which runes of a rendered row are matched by the
+// query in the cells it applies to.
+func (rt replTable) matchedRunes(runes []rune, q tableQuery) []bool {
+	// starts[i] is the display column where runes[i] starts
+	starts := make([]int, len(runes))
+	for i := 1; i < len(runes); i++ {
+		starts[i] = starts[i-1] + ansi.StringWidth(string(runes[i-1]))
+	}
+
+	highlighted := make([]bool, len(runes))
+	paddingLeft := rt.styles.Cell.GetPaddingLeft()
+	paddingRight := rt.styles.Cell.GetPaddingRight()
+	start := 0
+	for column, c := range rt.columns {
+		from := sort.SearchInts(starts, start+paddingLeft)
+		to := sort.SearchInts(starts, start+paddingLeft+c.Width)
+		start += paddingLeft + c.Width + paddingRight
+
+		if q.column >= 0 && q.column != column {
+			continue
+		}
+
+		for _, i := range matchCell(string(runes[from:to]), q.value) {
+			highlighted[from+i] = true
+		}
+	}
+
+	return highlighted
+}
+
+func (rt replTable) SettingFilter() bool {
+	return rt.settingFilter
+}
+
+// Filtered returns true if a filter or a search is being typed or applied.
+func (rt replTable) Filtered() bool {
+	return rt.settingFilter || rt.filter.Value() != ""
+}
@@ -0,0 +1,111 @@
+package vorl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestMatchedRunesWideCells(t *testing.T) {
+	table := newTable([][]string{{"name", "city"}, {"日本語", "tokyo"}}, nil, nil, 80, 10, DefaultKeyMap())
+	table.filter.SetValue("city:tok")
+
+	plain := ansi.Strip(strings.Split(table.table.View(), "\n")[2])
+	runes := []rune(plain)
+	matched := table.matchedRunes(runes, table.query())
+
+	got := ""
+	for i, m := range matched {
+		if m {
+			got += string(runes[i])
+		}
+	}
+	if got != "tok" {
+		t.Errorf("matched %q in %q, want %q", got, plain, "tok")
+	}
+
+	if slices.Contains(matched[:3], true) {
+		t.Errorf("matched the name column")
+	}
+}
+
+func TestMatchCell(t *testing.T) {
+	tests := []struct {
+		cell  string
+		value string
+		want  []int
+	}{
+		{"running", "run", []int{0, 1, 2}},
+		{"Running", "NING", []int{3, 4, 5, 6}},
+		{"api-server", "asr", []int{0, 4, 6}},
+		{"api-server", "xyz", nil},
+		{"api", "", nil},
+		{"", "a", nil},
+		{"日本語", "本", []int{1}},
+		{"aab", "ab", []int{1, 2}},
+	}
+
+	for _, tt := range tests {
+		if got := matchCell(tt.cell, tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("matchCell(%q, %q) = %v, want %v", tt.cell, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseTableQuery(t *testing.T) {
+	columns := []table.Column{{Title: "Name"}, {Title: "Status"}}
+
+	tests := []struct {
+		query string
+		want  tableQuery
+	}{
+		{"api", tableQuery{column: -1, value: "api"}},
+		{"status:run", tableQuery{column: 1, value: "run"}},
+		{" NAME :api", tableQuery{column: 0, value: "api"}},
+		{"age:10", tableQuery{column: -1, value: "age:10"}},
+		{"name:", tableQuery{column: 0, value: ""}},
+		{"", tableQuery{column: -1, value: ""}},
+	}
+
+	for _, tt := range tests {
+		if got := parseTableQuery(tt.query, columns); got != tt.want {
+			t.Errorf("parseTableQuery(%q) = %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestJumpToMatch(t *testing.T) {
+	rows := [][]string{{"name"}, {"api"}, {"db"}, {"api-2"}, {"cache"}}
+
+	tests := []struct {
+		name      string
+		query     string
+		from      int
+		direction int
+		want      int
+	}{
+		{"forward", "api", 1, 1, 2},
+		{"from a match", "api", 0, 1, 0},
+		{"wraps forward", "api", 3, 1, 0},
+		{"backward", "api", 1, -1, 0},
+		{"wraps backward", "api", -1, -1, 2},
+		{"no match", "zzz", 1, 1, 3},
+		{"empty query", "", 1, 1, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := newTable(rows, nil, nil, 80, 10, DefaultKeyMap())
+			table.table.SetCursor(3)
+			table.filter.SetValue(tt.query)
+
+			table.jumpToMatch(tt.from, tt.direction)
+			if got := table.table.Cursor(); got != tt.want {
+				t.Errorf("cursor = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
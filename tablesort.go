@@ -0,0 +1,229 @@
+package vorl
+
+import (
+	"cmp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// sortKey is a column the table is sorted by.
+type sortKey struct {
+	column     int
+	descending bool
+}
+
+// compareCells compares two cells as numbers if both of them are, and in
+// natural order otherwise, so "file9" goes before "file10".
+func compareCells(a string, b string) int {
+	na, errA := strconv.ParseFloat(strings.TrimSpace(a), 64)
+	nb, errB := strconv.ParseFloat(strings.TrimSpace(b), 64)
+	if errA == nil && errB == nil {
+		return cmp.Compare(na, nb)
+	}
+
+	return naturalCompare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// naturalCompare compares the strings chunk by chunk, where runs of digits
+// are compared by their numeric value.
+func naturalCompare(a string, b string) int {
+	for a != "" && b != "" {
+		var chunkA, chunkB string
+		chunkA, a = nextChunk(a)
+		chunkB, b = nextChunk(b)
+
+		if isDigit(chunkA[0]) && isDigit(chunkB[0]) {
+			// longer numbers are bigger once leading zeros are removed
+			chunkA = strings.TrimLeft(chunkA, "0")
+			chunkB = strings.TrimLeft(chunkB, "0")
+			if len(chunkA) != len(chunkB) {
+				return cmp.Compare(len(chunkA), len(chunkB))
+			}
+		}
+
+		if c := strings.Compare(chunkA, chunkB); c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(a), len(b))
+}
+
+// nextChunk splits the leading run of digits or non-digits from s.
+func nextChunk(s string) (string, string) {
+	digits := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func rowCell(row table.Row, column int) string {
+	if column < len(row) {
+		return row[column]
+	}
+	return ""
+}
+
+// sortBy makes the column the first sort key, or reverses it if it already
+// is. The previous keys are kept to break ties.
+func (rt *replTable) sortBy(column int) {
+	if len(rt.sortKeys) > 0 && rt.sortKeys[0].column == column {
+		rt.sortKeys[0].descending = !rt.sortKeys[0].descending
+	} else {
+		keys := []sortKey{{column: column}}
+		for _, k := range rt.sortKeys {
+			if k.column != column {
+				keys = append(keys, k)
+			}
+		}
+		rt.sortKeys = keys
+	}
+
+	rt.refreshRows()
+}
+
+func (rt *replTable) clearSort() {
+	rt.sortKeys = nil
+	rt.refreshRows()
+}
+
+// sortedRows returns the rows in the order of the sort keys.
+func (rt replTable) sortedRows() []table.Row {
+	rows := append([]table.Row{}, rt.rows...)
+	if len(rt.sortKeys) == 0 {
+		return rows
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, k := range rt.sortKeys {
+			c := compareCells(rowCell(rows[i], k.column), rowCell(rows[j], k.column))
+			if c == 0 {
+				continue
+			}
+
+			if k.descending {
+				return c > 0
+			}
+			return c < 0
+		}
+
+		return false
+	})
+
+	return rows
+}
+
+// sortedColumns returns the columns with an arrow on the ones the table is
+// sorted by, numbered by priority if there are several. Titles are cut to
+// leave room for the arrow in narrow columns.
+func (rt replTable) sortedColumns() []table.Column {
+	columns := append([]table.Column{}, rt.columns...)
+	for i, k := range rt.sortKeys {
+		if k.column >= len(columns) {
+			continue
+		}
+
+		arrow := " ▲"
+		if k.descending {
+			arrow = " ▼"
+		}
+		if len(rt.sortKeys) > 1 {
+			arrow += strconv.Itoa(i + 1)
+		}
+
+		title := columns[k.column].Title
+		width := max(columns[k.column].Width-ansi.StringWidth(arrow), 0)
+		if ansi.StringWidth(title) > width {
+			title = ansi.Truncate(title, width, "…")
+		}
+		columns[k.column].Title = title + arrow
+	}
+
+	return columns
+}
+
+// shownRows returns the header and the rows as they are shown, in the order
+// of the sort keys and without the rows left out by the filter.
+func (rt replTable) shownRows() [][]string {
+	header := make([]string, len(rt.columns))
+	for i, c := range rt.columns {
+		header[i] = c.Title
+	}
+
+	rows := [][]string{header}
+	for _, row := range rt.table.Rows() {
+		rows = append(rows, row)
+	}
+
+	return rows
+}
+
+// copyRows copies the rows to the clipboard of the terminal, with the cells
+// separated by tabs. The escape sequence is printed with the output of the
+// program, along with how many rows were copied.
+func copyRows(rows [][]string) tea.Cmd {
+	lines := make([]string, len(rows))
+	for i, row := range rows {
+		lines[i] = strings.Join(row, "\t")
+	}
+	text := strings.Join(lines, "\n")
+
+	return tea.Printf("%scopied %d rows", ansi.SetSystemClipboard(text), len(rows)-1)
+}
+
+// startSave starts typing the file the rows shown are saved to.
+func (rt *replTable) startSave() tea.Cmd {
+	rt.saving = true
+	rt.saveInput.SetValue("")
+	return rt.saveInput.Focus()
+}
+
+// Saving returns true if the file to save the rows to is being typed.
+func (rt replTable) Saving() bool {
+	return rt.saving
+}
+
+// saveUpdate reads the file to save the rows to. They are saved as they are
+// sorted and filtered, as CommandResultSaveTo saves a table.
+func (rt replTable) saveUpdate(msg tea.KeyMsg) (replTable, tea.Cmd) {
+	switch {
+	case key.Matches(msg, rt.keyMap.AcceptFilter):
+		rt.saving = false
+		rt.saveInput.Blur()
+
+		file := strings.TrimSpace(rt.saveInput.Value())
+		if file == "" {
+			return rt, nil
+		}
+
+		result := CommandResultSaveTo{
+			File:   file,
+			Result: CommandResultTable{Table: rt.shownRows()},
+		}
+		return rt, func() tea.Msg {
+			return result
+		}
+
+	case key.Matches(msg, rt.keyMap.ClearFilter):
+		rt.saving = false
+		rt.saveInput.Blur()
+		return rt, nil
+	}
+
+	var cmd tea.Cmd
+	rt.saveInput, cmd = rt.saveInput.Update(msg)
+	return rt, cmd
+}
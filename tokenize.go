@@ -0,0 +1,192 @@
+package vorl
+
+import "strings"
+
+// Token is a word of the input, with quotes and escapes already removed.
+type Token struct {
+	Value string
+
+	// Start and End are the byte offsets of the token in the input,
+	// including its quotes.
+	Start int
+	End   int
+
+	// Quoted is true if any part of the token was quoted or escaped, which
+	// is used to tell an operator like '>' from the argument "'>'".
+	Quoted bool
+}
+
+// Tokens is the result of Tokenize.
+type Tokens struct {
+	Tokens []Token
+
+	// Unterminated is true if the input ends inside quotes or right after a
+	// backslash.
+	Unterminated bool
+
+	// Cursor is the index of the token under the cursor, or -1 if the
+	// cursor is not on a token, e.g. after a space where a new argument
+	// would start.
+	Cursor int
+}
+
+// Values returns the values of the tokens.
+func (t Tokens) Values() []string {
+	values := make([]string, len(t.Tokens))
+	for i, token := range t.Tokens {
+		values[i] = token.Value
+	}
+
+	return values
+}
+
+// Tokenize splits the input in words as a shell does. Words are separated
+// by whitespace, single quotes keep their content literally, double quotes
+// allow escaping '"' and '\' with a backslash, and outside quotes a
+// backslash escapes any character. The operators '&&', '||' and ';' are
+// tokens of their own when they are not quoted or escaped, even without
+// spaces around them. cursor is a byte offset in input; pass -1 if it is
+// not needed.
+func Tokenize(input string, cursor int) Tokens {
+	result := Tokens{
+		Tokens: []Token{},
+		Cursor: -1,
+	}
+
+	var (
+		value   strings.Builder
+		inToken bool
+		start   int
+		quoted  bool
+		quote   rune
+		escaped bool
+	)
+
+	endToken := func(end int) {
+		if !inToken {
+			return
+		}
+
+		result.Tokens = append(result.Tokens, Token{
+			Value:  value.String(),
+			Start:  start,
+			End:    end,
+			Quoted: quoted,
+		})
+		value.Reset()
+		inToken = false
+		quoted = false
+	}
+
+	skip := 0
+	for i, r := range input {
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		if op := operatorAt(input, i); op != "" && quote == 0 && !escaped {
+			endToken(i)
+			result.Tokens = append(result.Tokens, Token{
+				Value: op,
+				Start: i,
+				End:   i + len(op),
+			})
+			skip = len(op) - 1
+			continue
+		}
+
+		if !inToken && !isTokenSpace(r) {
+			inToken = true
+			start = i
+		}
+
+		switch {
+		case escaped:
+			escaped = false
+			if quote == '"' && r != '"' && r != '\\' {
+				value.WriteRune('\\')
+			}
+			value.WriteRune(r)
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				value.WriteRune(r)
+			}
+
+		case quote == '"':
+			switch r {
+			case '"':
+				quote = 0
+			case '\\':
+				escaped = true
+			default:
+				value.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			quote = r
+			quoted = true
+
+		case r == '\\':
+			escaped = true
+			quoted = true
+
+		case isTokenSpace(r):
+			endToken(i)
+
+		default:
+			value.WriteRune(r)
+		}
+	}
+
+	result.Unterminated = quote != 0 || escaped
+	endToken(len(input))
+
+	if cursor >= 0 {
+		for i, token := range result.Tokens {
+			if token.Start <= cursor && cursor <= token.End {
+				result.Cursor = i
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+// parseRedirection splits an input ending in '> file' in the command and
+// the file. The '>' must not be quoted.
+func parseRedirection(input string) (string, string, bool) {
+	tokens := Tokenize(input, -1).Tokens
+	n := len(tokens)
+	if n < 3 || tokens[n-2].Quoted || tokens[n-2].Value != ">" {
+		return input, "", false
+	}
+
+	command := strings.TrimSpace(input[:tokens[n-2].Start])
+	return command, tokens[n-1].Value, true
+}
+
+// operatorAt returns the command operator starting at the byte offset i of
+// input, or "" if there is none.
+func operatorAt(input string, i int) string {
+	for _, op := range []string{"&&", "||", ";"} {
+		if strings.HasPrefix(input[i:], op) {
+			return op
+		}
+	}
+
+	return ""
+}
+
+// isOperator returns true if the token is an unquoted command operator.
+func isOperator(token Token) bool {
+	return !token.Quoted && token.Value != "" && operatorAt(token.Value, 0) == token.Value
+}
+
+func isTokenSpace(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
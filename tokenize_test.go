@@ -0,0 +1,98 @@
+package vorl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name         string
+		input        string
+		values       []string
+		quoted       []bool
+		unterminated bool
+	}{
+		{"empty", "", []string{}, []bool{}, false},
+		{"spaces", "  ls   -l\t/tmp ", []string{"ls", "-l", "/tmp"}, []bool{false, false, false}, false},
+		{"single quotes", `echo 'a "b" \c'`, []string{"echo", `a "b" \c`}, []bool{false, true}, false},
+		{"double quotes", `echo "a \"b\" \c"`, []string{"echo", `a "b" \c`}, []bool{false, true}, false},
+		{"escaped space", `cat a\ b`, []string{"cat", "a b"}, []bool{false, true}, false},
+		{"joined quotes", `a'b'"c"`, []string{"abc"}, []bool{true}, false},
+		{"quoted operator", `echo '>' f`, []string{"echo", ">", "f"}, []bool{false, true, false}, false},
+		{"open quote", `echo "a b`, []string{"echo", "a b"}, []bool{false, true}, true},
+		{"trailing backslash", `echo a\`, []string{"echo", "a"}, []bool{false, true}, true},
+		{"operators", "a;b&&c || d", []string{"a", ";", "b", "&&", "c", "||", "d"}, []bool{false, false, false, false, false, false, false}, false},
+		{"quoted operators", `'a;b' "&&" \;`, []string{"a;b", "&&", ";"}, []bool{true, true, true}, false},
+		{"single ampersand", "a&b", []string{"a&b"}, []bool{false}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := Tokenize(tt.input, -1)
+
+			if got := tokens.Values(); !slices.Equal(got, tt.values) {
+				t.Errorf("values = %q, want %q", got, tt.values)
+			}
+
+			quoted := []bool{}
+			for _, token := range tokens.Tokens {
+				quoted = append(quoted, token.Quoted)
+			}
+			if !slices.Equal(quoted, tt.quoted) {
+				t.Errorf("quoted = %v, want %v", quoted, tt.quoted)
+			}
+
+			if tokens.Unterminated != tt.unterminated {
+				t.Errorf("unterminated = %v, want %v", tokens.Unterminated, tt.unterminated)
+			}
+		})
+	}
+}
+
+func TestTokenizeCursor(t *testing.T) {
+	tests := []struct {
+		input  string
+		cursor int
+		want   int
+	}{
+		{"ls -l", 0, 0},
+		{"ls -l", 2, 0},
+		{"ls -l", 4, 1},
+		{"ls  -l", 3, -1},
+		{"ls ", 3, -1},
+		{`cat 'a b'`, 7, 1},
+	}
+
+	for _, tt := range tests {
+		if got := Tokenize(tt.input, tt.cursor).Cursor; got != tt.want {
+			t.Errorf("Tokenize(%q, %d).Cursor = %d, want %d", tt.input, tt.cursor, got, tt.want)
+		}
+	}
+}
+
+func TestParseRedirection(t *testing.T) {
+	tests := []struct {
+		input      string
+		command    string
+		file       string
+		redirected bool
+	}{
+		{"ls > out.txt", "ls", "out.txt", true},
+		{"ls -l   >   'my file'", "ls -l", "my file", true},
+		{"ls", "ls", "", false},
+		{"ls >", "ls >", "", false},
+		{"> out.txt", "> out.txt", "", false},
+		{"echo '>' out.txt", "echo '>' out.txt", "", false},
+		{"ls >out.txt", "ls >out.txt", "", false},
+		{"ls > a b", "ls > a b", "", false},
+	}
+
+	for _, tt := range tests {
+		command, file, redirected := parseRedirection(tt.input)
+		if command != tt.command || file != tt.file || redirected != tt.redirected {
+			t.Errorf("parseRedirection(%q) = %q, %q, %v, want %q, %q, %v",
+				tt.input, command, file, redirected, tt.command, tt.file, tt.redirected)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+package vorl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/help"
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// TreeNode is a node of a CommandResultTree.
+type TreeNode struct {
+	Name string
+
+	// Value is passed to OnSelect instead of the name.
+	Value string
+
+	Children []TreeNode
+
+	// LoadChildren is called the first time the node is expanded if it has
+	// no Children. Nodes with neither of them are leaves.
+	LoadChildren func() ([]TreeNode, error)
+
+	// Expanded shows the children of the node from the start.
+	Expanded bool
+
+	// OnSelect receives the Value of the node, or its name if it has no
+	// value. Nodes without it cannot be selected.
+	OnSelect func(selected string) interface{}
+}
+
+func (n TreeNode) value() string {
+	if n.Value != "" {
+		return n.Value
+	}
+	return n.Name
+}
+
+// treeText renders the nodes one per line, indented by depth. Children that
+// are loaded lazily are fetched, so it must be called out of Update.
+func treeText(nodes []TreeNode) (string, error) {
+	lines := []string{}
+
+	var walk func(nodes []TreeNode, depth int) error
+	walk = func(nodes []TreeNode, depth int) error {
+		for _, node := range nodes {
+			lines = append(lines, strings.Repeat("  ", depth)+node.Name)
+
+			children := node.Children
+			if len(children) == 0 && node.LoadChildren != nil {
+				var err error
+				children, err = node.LoadChildren()
+				if err != nil {
+					return err
+				}
+			}
+
+			if err := walk(children, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := walk(nodes, 0); err != nil {
+		return "", err
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+// treeNode is the state of a TreeNode in the interactive tree. Nodes are
+// shared by pointer, so children loaded in the background can be attached
+// to their parent.
+type treeNode struct {
+	node     TreeNode
+	parent   *treeNode
+	children []*treeNode
+	depth    int
+	expanded bool
+
+	// loaded is false until the children of a lazy node are fetched
+	loaded  bool
+	loading bool
+	err     error
+}
+
+func newTreeNodes(nodes []TreeNode, parent *treeNode, depth int) []*treeNode {
+	result := make([]*treeNode, len(nodes))
+	for i, node := range nodes {
+		n := &treeNode{
+			node:     node,
+			parent:   parent,
+			depth:    depth,
+			expanded: node.Expanded,
+			loaded:   len(node.Children) > 0 || node.LoadChildren == nil,
+		}
+		n.children = newTreeNodes(node.Children, n, depth+1)
+		result[i] = n
+	}
+
+	return result
+}
+
+func (n *treeNode) expandable() bool {
+	return len(n.children) > 0 || !n.loaded
+}
+
+// matches returns true if the name of the node or of any of its loaded
+// descendants contains the query.
+func (n *treeNode) matches(query string) bool {
+	if strings.Contains(strings.ToLower(n.node.Name), query) {
+		return true
+	}
+
+	for _, child := range n.children {
+		if child.matches(query) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// treeChildrenLoaded is sent when the children of a lazy node are fetched.
+type treeChildrenLoaded struct {
+	node     *treeNode
+	children []TreeNode
+	err      error
+}
+
+type replTree struct {
+	roots []*treeNode
+
+	// rows are the nodes shown, in order
+	rows   []*treeNode
+	cursor int
+	offset int
+
+	width  int
+	height int
+
+	interactiveMode bool
+	executedCommand bool
+	keyMap          KeyMap
+	help            help.Model
+
+	filter        textinput.Model
+	settingFilter bool
+}
+
+func newTree(nodes []TreeNode, width int, height int, keyMap KeyMap) replTree {
+	filter := textinput.New()
+	filter.Prompt = "filter: "
+
+	t := replTree{
+		roots:  newTreeNodes(nodes, nil, 0),
+		width:  width,
+		height: height - 6,
+		keyMap: keyMap,
+		help:   help.New(),
+		filter: filter,
+	}
+	t.refreshRows()
+
+	return t
+}
+
+// refreshRows builds the rows again after nodes are expanded, collapsed or
+// filtered, keeping the cursor on the same node if it is still shown.
+func (t *replTree) refreshRows() {
+	var current *treeNode
+	if t.cursor < len(t.rows) {
+		current = t.rows[t.cursor]
+	}
+
+	query := strings.ToLower(t.filter.Value())
+	rows := []*treeNode{}
+
+	var walk func(nodes []*treeNode)
+	walk = func(nodes []*treeNode) {
+		for _, n := range nodes {
+			if query != "" {
+				// ancestors of matching nodes are shown expanded
+				if n.matches(query) {
+					rows = append(rows, n)
+					walk(n.children)
+				}
+				continue
+			}
+
+			rows = append(rows, n)
+			if n.expanded {
+				walk(n.children)
+			}
+		}
+	}
+	walk(t.roots)
+
+	t.rows = rows
+	t.cursor = min(t.cursor, max(len(rows)-1, 0))
+	for i, n := range rows {
+		if n == current {
+			t.cursor = i
+			break
+		}
+	}
+	t.scroll()
+}
+
+// moveCursor moves the cursor by n rows, stopping at the first and last
+// ones.
+func (t *replTree) moveCursor(n int) {
+	t.cursor = max(min(t.cursor+n, len(t.rows)-1), 0)
+	t.scroll()
+}
+
+// scroll moves the window of rows shown so the cursor is inside it.
+func (t *replTree) scroll() {
+	height := t.visibleHeight()
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+height {
+		t.offset = t.cursor - height + 1
+	}
+	t.offset = max(min(t.offset, len(t.rows)-height), 0)
+}
+
+func (t replTree) visibleHeight() int {
+	return max(min(len(t.rows), t.height), 1)
+}
+
+func (t replTree) View() string {
+	selectedStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color("229")).
+		Background(lipgloss.Color("57"))
+
+	lines := []string{}
+	end := min(t.offset+t.visibleHeight(), len(t.rows))
+	for i := t.offset; i < end; i++ {
+		line := t.rowView(t.rows[i])
+		if t.interactiveMode && i == t.cursor {
+			line = selectedStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+
+	if len(t.rows) == 0 {
+		lines = append(lines, "no matching nodes")
+	}
+
+	if !t.interactiveMode {
+		return strings.Join(lines, "\n")
+	}
+
+	if t.settingFilter || t.filter.Value() != "" {
+		lines = append(lines, t.filter.View())
+	}
+
+	lines = append(lines, t.help.ShortHelpView(t.helpKeys()))
+
+	return strings.Join(lines, "\n")
+}
+
+func (t replTree) rowView(n *treeNode) string {
+	marker := "  "
+	if n.expandable() {
+		marker = "▸ "
+
+		// filtered nodes are shown with their matching children
+		if n.expanded || t.filter.Value() != "" {
+			marker = "▾ "
+		}
+	}
+
+	row := strings.Repeat("  ", n.depth) + marker + n.node.Name
+	if n.loading {
+		row += " (loading...)"
+	} else if n.err != nil {
+		row += fmt.Sprintf(" (ERROR: %v)", n.err)
+	}
+
+	return row
+}
+
+func (t replTree) helpKeys() []key.Binding {
+	if t.settingFilter {
+		return []key.Binding{t.keyMap.AcceptFilter, t.keyMap.ClearFilter}
+	}
+
+	keys := []key.Binding{
+		t.keyMap.LineUp,
+		t.keyMap.LineDown,
+		t.keyMap.Expand,
+		t.keyMap.Collapse,
+		t.keyMap.Filter,
+	}
+	return append(keys, t.keyMap.interactionHelpKeys()...)
+}
+
+func (t replTree) Update(msg tea.Msg) (replTree, tea.Cmd) {
+	t.executedCommand = false
+
+	if !t.interactiveMode {
+		return t, nil
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return t, nil
+	}
+
+	if t.settingFilter {
+		return t.filterUpdate(keyMsg)
+	}
+
+	var cmd tea.Cmd
+
+	switch {
+	case key.Matches(keyMsg, t.keyMap.LineUp):
+		t.moveCursor(-1)
+
+	case key.Matches(keyMsg, t.keyMap.LineDown):
+		t.moveCursor(1)
+
+	case key.Matches(keyMsg, t.keyMap.PageUp):
+		t.moveCursor(-t.visibleHeight())
+
+	case key.Matches(keyMsg, t.keyMap.PageDown):
+		t.moveCursor(t.visibleHeight())
+
+	case key.Matches(keyMsg, t.keyMap.GotoTop):
+		t.moveCursor(-len(t.rows))
+
+	case key.Matches(keyMsg, t.keyMap.GotoBottom):
+		t.moveCursor(len(t.rows))
+
+	case key.Matches(keyMsg, t.keyMap.Expand):
+		cmd = t.expand()
+
+	case key.Matches(keyMsg, t.keyMap.Collapse):
+		t.collapse()
+
+	case key.Matches(keyMsg, t.keyMap.Filter):
+		t.settingFilter = true
+		cmd = t.filter.Focus()
+
+	case key.Matches(keyMsg, t.keyMap.ClearFilter):
+		t.filter.SetValue("")
+		t.refreshRows()
+
+	case key.Matches(keyMsg, t.keyMap.Select):
+		if len(t.rows) == 0 {
+			break
+		}
+
+		node := t.rows[t.cursor].node
+		if node.OnSelect == nil {
+			break
+		}
+
+		cmd = selectCmd(t.View(), node.Name, func() interface{} {
+			return node.OnSelect(node.value())
+		})
+		t.executedCommand = true
+	}
+
+	return t, cmd
+}
+
+func (t replTree) filterUpdate(msg tea.KeyMsg) (replTree, tea.Cmd) {
+	switch {
+	case key.Matches(msg, t.keyMap.AcceptFilter):
+		t.settingFilter = false
+		t.filter.Blur()
+		return t, nil
+
+	case key.Matches(msg, t.keyMap.ClearFilter):
+		t.settingFilter = false
+		t.filter.Blur()
+		t.filter.SetValue("")
+		t.refreshRows()
+		return t, nil
+	}
+
+	var cmd tea.Cmd
+	t.filter, cmd = t.filter.Update(msg)
+	t.refreshRows()
+
+	return t, cmd
+}
+
+// expand shows the children of the node under the cursor, fetching them if
+// needed. If they are already shown, the cursor moves to the first one.
+func (t *replTree) expand() tea.Cmd {
+	if len(t.rows) == 0 {
+		return nil
+	}
+
+	n := t.rows[t.cursor]
+	if !n.expandable() {
+		return nil
+	}
+
+	if n.expanded && len(n.children) > 0 {
+		t.cursor++
+		t.scroll()
+		return nil
+	}
+
+	n.expanded = true
+
+	var cmd tea.Cmd
+	if !n.loaded && !n.loading {
+		n.loading = true
+		n.err = nil
+		load := n.node.LoadChildren
+		cmd = func() tea.Msg {
+			children, err := load()
+			return treeChildrenLoaded{node: n, children: children, err: err}
+		}
+	}
+
+	t.refreshRows()
+	return cmd
+}
+
+// collapse hides the children of the node under the cursor, or moves the
+// cursor to its parent if they are not shown.
+func (t *replTree) collapse() {
+	if len(t.rows) == 0 {
+		return
+	}
+
+	n := t.rows[t.cursor]
+	if n.expanded {
+		n.expanded = false
+		t.refreshRows()
+		return
+	}
+
+	if n.parent == nil {
+		return
+	}
+
+	for i, row := range t.rows {
+		if row == n.parent {
+			t.cursor = i
+			break
+		}
+	}
+	t.scroll()
+}
+
+// childrenLoaded attaches the children fetched in the background to the
+// node, even if its tree is not shown anymore. Nodes that failed to load
+// can be expanded again to retry.
+func (n *treeNode) childrenLoaded(children []TreeNode, err error) {
+	n.loading = false
+
+	if err != nil {
+		n.err = err
+		n.expanded = false
+		return
+	}
+
+	n.loaded = true
+	n.children = newTreeNodes(children, n, n.depth+1)
+}
+
+// owns returns true if the node belongs to the tree.
+func (t replTree) owns(n *treeNode) bool {
+	for n.parent != nil {
+		n = n.parent
+	}
+
+	for _, root := range t.roots {
+		if root == n {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (t *replTree) SetInteractiveMode(enabled bool) {
+	t.interactiveMode = enabled
+	t.settingFilter = false
+	t.filter.Blur()
+}
+
+func (t replTree) ExecutedCommand() bool {
+	return t.executedCommand
+}
+
+func (t replTree) SettingFilter() bool {
+	return t.settingFilter
+}
+
+// Filtered returns true if a filter is being typed or applied.
+func (t replTree) Filtered() bool {
+	return t.settingFilter || t.filter.Value() != ""
+}
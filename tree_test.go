@@ -0,0 +1,173 @@
+package vorl
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func treeRows(t replTree) []string {
+	names := []string{}
+	for _, n := range t.rows {
+		names = append(names, n.node.Name)
+	}
+	return names
+}
+
+func newTestTree(nodes []TreeNode) replTree {
+	tree := newTree(nodes, 80, 30, DefaultKeyMap())
+	tree.SetInteractiveMode(true)
+	return tree
+}
+
+func TestTreeExpandAndCollapse(t *testing.T) {
+	tree := newTestTree([]TreeNode{
+		{Name: "a", Children: []TreeNode{{Name: "a1"}, {Name: "a2"}}},
+		{Name: "b"},
+	})
+
+	if got, want := treeRows(tree), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Fatalf("rows = %q, want %q", got, want)
+	}
+
+	tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if got, want := treeRows(tree), []string{"a", "a1", "a2", "b"}; !slices.Equal(got, want) {
+		t.Fatalf("rows after expanding = %q, want %q", got, want)
+	}
+
+	// expanding again moves to the first child, and collapsing to its parent
+	tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if tree.cursor != 1 {
+		t.Errorf("cursor = %d, want 1", tree.cursor)
+	}
+	tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if tree.cursor != 0 {
+		t.Errorf("cursor = %d, want 0", tree.cursor)
+	}
+
+	tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	if got, want := treeRows(tree), []string{"a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("rows after collapsing = %q, want %q", got, want)
+	}
+}
+
+func TestTreeFilterShowsAncestors(t *testing.T) {
+	tree := newTestTree([]TreeNode{
+		{Name: "src", Children: []TreeNode{
+			{Name: "cmd", Children: []TreeNode{{Name: "main.go"}}},
+			{Name: "util.go"},
+		}},
+		{Name: "README"},
+	})
+
+	tree, _ = tree.Update(runes("/"))
+	tree, _ = tree.Update(runes("main"))
+	if got, want := treeRows(tree), []string{"src", "cmd", "main.go"}; !slices.Equal(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+
+	tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if got, want := treeRows(tree), []string{"src", "README"}; !slices.Equal(got, want) {
+		t.Errorf("rows after clearing the filter = %q, want %q", got, want)
+	}
+}
+
+func TestTreeLazyChildren(t *testing.T) {
+	calls := 0
+	fail := true
+	tree := newTestTree([]TreeNode{{
+		Name: "lazy",
+		LoadChildren: func() ([]TreeNode, error) {
+			calls++
+			if fail {
+				return nil, errors.New("failed")
+			}
+			return []TreeNode{{Name: "child"}}, nil
+		},
+	}})
+
+	// a failed load collapses the node so it can be expanded again
+	tree, cmd := tree.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if cmd == nil || !tree.rows[0].loading {
+		t.Fatal("expanding the node did not load its children")
+	}
+	msg := cmd().(treeChildrenLoaded)
+	msg.node.childrenLoaded(msg.children, msg.err)
+	tree.refreshRows()
+
+	if n := tree.rows[0]; n.err == nil || n.loading || n.expanded {
+		t.Fatalf("after a failed load: err %v, loading %v, expanded %v", n.err, n.loading, n.expanded)
+	}
+
+	fail = false
+	tree, cmd = tree.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if cmd == nil {
+		t.Fatal("expanding the node again did not retry")
+	}
+	msg = cmd().(treeChildrenLoaded)
+	msg.node.childrenLoaded(msg.children, msg.err)
+	tree.refreshRows()
+
+	if got, want := treeRows(tree), []string{"lazy", "child"}; !slices.Equal(got, want) {
+		t.Errorf("rows = %q, want %q", got, want)
+	}
+	if tree.rows[0].err != nil {
+		t.Errorf("the error was kept after loading: %v", tree.rows[0].err)
+	}
+
+	tree, _ = tree.Update(tea.KeyMsg{Type: tea.KeyLeft})
+	tree, cmd = tree.Update(tea.KeyMsg{Type: tea.KeyRight})
+	if cmd != nil || calls != 2 {
+		t.Errorf("the children were loaded %d times, want 2", calls)
+	}
+}
+
+func TestTreeChildrenLoadedWhileHidden(t *testing.T) {
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModel, _ := r.model.Update(CommandResultTree{Nodes: []TreeNode{{
+		Name:         "lazy",
+		LoadChildren: func() ([]TreeNode, error) { return []TreeNode{{Name: "child"}}, nil },
+	}}})
+	newModel, _ = newModel.Update(tea.KeyMsg{Type: tea.KeyCtrlUp})
+
+	tree := newModel.(model).treeResult
+	newModel, cmd := newModel.Update(tea.KeyMsg{Type: tea.KeyRight})
+	loaded := cmd()
+
+	// the tree is replaced before its children are loaded
+	newModel, _ = newModel.Update(CommandResultSimple("done"))
+	newModel.Update(loaded)
+
+	if n := tree.roots[0]; n.loading || len(n.children) != 1 {
+		t.Errorf("after loading while hidden: loading %v, %d children", n.loading, len(n.children))
+	}
+}
+
+func TestTreeText(t *testing.T) {
+	text, err := treeText([]TreeNode{
+		{Name: "a", Children: []TreeNode{{Name: "a1"}}},
+		{Name: "b", LoadChildren: func() ([]TreeNode, error) {
+			return []TreeNode{{Name: "b1"}}, nil
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "a\n  a1\nb\n  b1"; text != want {
+		t.Errorf("treeText = %q, want %q", text, want)
+	}
+
+	_, err = treeText([]TreeNode{{Name: "a", LoadChildren: func() ([]TreeNode, error) {
+		return nil, errors.New("failed")
+	}}})
+	if err == nil {
+		t.Error("the error loading the children was dropped")
+	}
+}
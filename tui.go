@@ -1,11 +1,14 @@
 package vorl
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"os"
-	"strings"
+	"sync"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -22,6 +25,9 @@ const (
 	replStateExecutingCommand
 	replStateListInteraction
 	replStateTableInteraction
+	replStateConfirmingPaste
+	replStateReadingInputAndTree
+	replStateTreeInteraction
 )
 
 type Interpreter interface {
@@ -31,12 +37,38 @@ type Interpreter interface {
 }
 
 type REPL struct {
-	model       model
-	historyFile string
+	model model
+
+	program   *tea.Program
+	programMu sync.Mutex
 }
 
-func NewREPL(interpreter Interpreter, prompt string, historyFile string) (*REPL, error) {
-	model, err := initialModel(interpreter, prompt, historyFile)
+func NewREPL(
+	interpreter Interpreter,
+	prompt string,
+	historyFile string,
+	opts ...Option,
+) (*REPL, error) {
+	options := replOptions{
+		keyMap: DefaultKeyMap(),
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if err := options.keyMap.Validate(); err != nil {
+		return nil, err
+	}
+
+	if options.historyStore == nil {
+		if historyFile != "" {
+			options.historyStore = NewFileHistoryStore(historyFile)
+		} else {
+			options.historyStore = NewMemoryHistoryStore()
+		}
+	}
+
+	model, err := initialModel(interpreter, prompt, options)
 	if err != nil {
 		return nil, err
 	}
@@ -49,14 +81,44 @@ func NewREPL(interpreter Interpreter, prompt string, historyFile string) (*REPL,
 func (r *REPL) Run() error {
 	p := tea.NewProgram(r.model)
 
+	r.programMu.Lock()
+	r.program = p
+	r.programMu.Unlock()
+
 	_, err := p.Run()
 	return err
 }
 
+// RefreshStatusBar builds the status bar again. It can be called from any
+// goroutine, e.g. when the interpreter state changes in the background.
+func (r *REPL) RefreshStatusBar() {
+	r.programMu.Lock()
+	defer r.programMu.Unlock()
+
+	if r.program != nil {
+		r.program.Send(refreshStatusBar{})
+	}
+}
+
+// CompactHistory removes repeated commands from the history store.
+func (r *REPL) CompactHistory() error {
+	return r.model.historyStore.Compact()
+}
+
 func (r *REPL) RunNonInteractive(command string) error {
-	result, err := r.model.interpreter.Exec(command)
-	if err != nil {
-		return err
+	var result interface{}
+	if args, ok := parseHistoryCommand(command); ok {
+		result = historyResult(r.model.history, args)
+	} else {
+		var err error
+		result, err = r.model.interpreter.Exec(command)
+		if err != nil {
+			return err
+		}
+
+		if err := checkResult(result); err != nil {
+			return err
+		}
 	}
 
 	switch result := result.(type) {
@@ -72,7 +134,7 @@ func (r *REPL) RunNonInteractive(command string) error {
 			return err
 		}
 
-		table := newTable(result.Table, nil, width, math.MaxInt)
+		table := newTable(result.Table, nil, nil, width, math.MaxInt, r.model.keyMap)
 		fmt.Println(table.View())
 
 	case CommandResultList:
@@ -81,8 +143,71 @@ func (r *REPL) RunNonInteractive(command string) error {
 			return err
 		}
 
-		list := newList(result.List, nil, width, math.MaxInt)
+		list := newList(result.items(), nil, nil, nil, width, math.MaxInt, r.model.keyMap)
 		fmt.Println(list.View())
+
+	case CommandResultPagedTable:
+		width, _, err := term.GetSize(0)
+		if err != nil {
+			return err
+		}
+
+		rows, err := result.fetchAll()
+		if err != nil {
+			return err
+		}
+
+		table := newTable(rows, nil, nil, width, math.MaxInt, r.model.keyMap)
+		fmt.Println(table.View())
+
+	case CommandResultPagedList:
+		width, _, err := term.GetSize(0)
+		if err != nil {
+			return err
+		}
+
+		items, err := result.fetchAll()
+		if err != nil {
+			return err
+		}
+
+		list := newList(items, nil, nil, nil, width, math.MaxInt, r.model.keyMap)
+		fmt.Println(list.View())
+
+	case CommandResultLiveTable:
+		width, _, err := term.GetSize(0)
+		if err != nil {
+			return err
+		}
+
+		// only the first rows are printed
+		if result.Stop != nil {
+			result.Stop()
+		}
+
+		table := newTable(result.Table, nil, nil, width, math.MaxInt, r.model.keyMap)
+		fmt.Println(table.View())
+
+	case CommandResultLiveList:
+		width, _, err := term.GetSize(0)
+		if err != nil {
+			return err
+		}
+
+		if result.Stop != nil {
+			result.Stop()
+		}
+
+		list := newList(result.Items, nil, nil, nil, width, math.MaxInt, r.model.keyMap)
+		fmt.Println(list.View())
+
+	case CommandResultTree:
+		text, err := treeText(result.Nodes)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(text)
 	}
 
 	return nil
@@ -91,6 +216,11 @@ func (r *REPL) RunNonInteractive(command string) error {
 type model struct {
 	interpreter Interpreter
 
+	// chain holds the commands of the command line still to run, and
+	// redirection is true if they can be redirected to a file
+	chain       []chainStep
+	redirection bool
+
 	textInput replInput
 
 	state replState
@@ -99,104 +229,284 @@ type model struct {
 
 	tableResult *replTable
 
+	treeResult *replTree
+
 	spinner spinner.Model
 
 	height int
 	width  int
 
-	historyFile string
+	historyStore HistoryStore
+
+	history []historyEntry
+
+	redactFn func(string) (string, bool)
+
+	keyMap KeyMap
+
+	pasteMode         PasteMode
+	pasteConfirmAbove int
+	pasteQueue        []string
+	pendingPaste      []string
+	stateBeforePaste  replState
+	pasteContinued    bool
+
+	promptFunc   PromptFunc
+	commandsRun  int
+	commandStart time.Time
+	lastInfo     PromptInfo
+
+	statusBarFunc     StatusBarFunc
+	statusBarPosition StatusBarPosition
+	statusBar         string
+
+	// resultStack holds the results hidden by drill-downs, and resultRoot
+	// is the command that showed the first of them
+	resultStack  []resultFrame
+	resultRoot   string
+	selectedFrom resultFrame
+	drillingDown bool
 }
 
 func initialModel(
 	interpreter Interpreter,
 	prompt string,
-	historyFile string,
+	options replOptions,
 ) (model, error) {
 	execFn := func(cmd string) tea.Cmd {
-		runCommandCmd := func() tea.Msg {
-			msg, err := interpreter.Exec(cmd)
-			if err != nil {
-				return commandError(err)
-			}
-
-			if msg == nil {
-				msg = CommandResultEmpty{}
-			}
-			return msg
+		steps := []chainStep{{command: cmd}}
+		if chain := parseChain(cmd); options.chaining && len(chain) > 0 {
+			steps = chain
 		}
 
 		sendCommandExecutedMsg := func() tea.Msg {
 			return commandExecuted(cmd)
 		}
 
-		return tea.Batch(runCommandCmd, sendCommandExecutedMsg)
+		// commandExecuted must be handled before the result so the history
+		// entry exists when its status is updated
+		return tea.Sequence(sendCommandExecutedMsg, runSteps(interpreter, steps, options.redirection))
 	}
 
-	initialHistory := []string{}
-	if historyFile != "" {
-		hb, err := os.ReadFile(historyFile)
-		if err != nil {
-			if !os.IsNotExist(err) {
-				return model{}, err
-			}
+	initialHistory, err := options.historyStore.Load()
+	if err != nil {
+		return model{}, err
+	}
+
+	history := make([]historyEntry, len(initialHistory))
+	for i, command := range initialHistory {
+		history[i] = historyEntry{command: command}
+		if !mayBeRedacted(command, options.redactionRules) {
+			history[i].run = command
 		}
+	}
 
-		commands := strings.Split(string(hb), "\n")
-		for _, c := range commands {
-			command := strings.TrimSpace(c)
-			if command == "" {
-				continue
-			}
-			initialHistory = append(initialHistory, command)
+	redactFn := func(cmd string) (string, bool) {
+		return redactCommand(cmd, interpreter, options.redactionRules)
+	}
+
+	searchFn := func(query string) []string {
+		results, err := options.historyStore.Search(query)
+		if err != nil {
+			return []string{}
 		}
+		return results
 	}
 
-	input := newInput(prompt, execFn, interpreter.Suggest, initialHistory)
+	input := newInput(
+		prompt,
+		execFn,
+		interpreter.Suggest,
+		redactFn,
+		searchFn,
+		initialHistory,
+		options.keyMap,
+	)
+	info := PromptInfo{Now: time.Now()}
+	if options.promptFunc != nil {
+		p := options.promptFunc(info)
+		input.SetPrompt(p.Main, p.Continuation)
+	}
+
+	statusBar := ""
+	if options.statusBarFunc != nil {
+		statusBar = options.statusBarFunc(info)
+	}
+	input.SetViMode(options.viMode)
+	input.executeAfterEdit = options.executeAfterEdit
+	if hinter, ok := interpreter.(ArgumentHinter); ok {
+		input.hintFn = hinter.Hint
+	}
+	if validator, ok := interpreter.(InputValidator); ok {
+		input.validateFn = validator.Validate
+	}
 
 	sp := spinner.New()
 	sp.Spinner = spinner.Dot
 	sp.Style = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 
 	return model{
-		interpreter: interpreter,
-		textInput:   input,
-		state:       replStateReadingInput,
-		spinner:     sp,
-		historyFile: historyFile,
+		interpreter:  interpreter,
+		redirection:  options.redirection,
+		textInput:    input,
+		state:        replStateReadingInput,
+		spinner:      sp,
+		historyStore: options.historyStore,
+		history:      history,
+		redactFn:     redactFn,
+		keyMap:       options.keyMap,
+
+		pasteMode:         options.pasteMode,
+		pasteConfirmAbove: options.pasteConfirmAbove,
+
+		promptFunc: options.promptFunc,
+		lastInfo:   info,
+
+		statusBarFunc:     options.statusBarFunc,
+		statusBarPosition: options.statusBarPosition,
+		statusBar:         statusBar,
 	}, nil
 }
 
+// refreshPrompt builds the prompt and the status bar again from lastInfo.
+func (m model) refreshPrompt() model {
+	if m.promptFunc != nil {
+		p := m.promptFunc(m.lastInfo)
+		m.textInput.SetPrompt(p.Main, p.Continuation)
+	}
+
+	if m.statusBarFunc != nil {
+		m.statusBar = m.statusBarFunc(m.lastInfo)
+	}
+
+	return m
+}
+
+// resolveBuiltin applies the built-ins that need the state of the model,
+// and returns the result to show.
+func (m model) resolveBuiltin(result tea.Msg) (model, tea.Msg) {
+	switch result := result.(type) {
+	case historyCommand:
+		return m, historyResult(m.history, result)
+
+	case setEditingMode:
+		m.textInput.SetViMode(result == "vi")
+		return m, CommandResultEmpty{}
+	}
+
+	return m, result
+}
+
+// runCommand runs a built-in command or passes it to the interpreter.
+func runCommand(interpreter Interpreter, command string) tea.Msg {
+	if args, ok := parseHistoryCommand(command); ok {
+		return historyCommand(args)
+	}
+
+	if mode, ok := parseSetEditingMode(command); ok {
+		return setEditingMode(mode)
+	}
+
+	msg, err := interpreter.Exec(command)
+	if err != nil {
+		return commandError(err)
+	}
+
+	if err := checkResult(msg); err != nil {
+		return commandError(err)
+	}
+
+	if msg == nil {
+		msg = CommandResultEmpty{}
+	}
+	return msg
+}
+
+// checkResult returns an error if a result cannot be shown, e.g. a table
+// without columns. Live results that cannot be shown are stopped.
+func checkResult(result interface{}) error {
+	errNoColumns := errors.New("the table has no columns")
+
+	switch result := result.(type) {
+	case CommandResultTable:
+		if len(result.Table) == 0 || len(result.Table[0]) == 0 {
+			return errNoColumns
+		}
+
+	case CommandResultPagedTable:
+		if len(result.Header) == 0 {
+			return errNoColumns
+		}
+
+	case CommandResultLiveTable:
+		if len(result.Table) == 0 || len(result.Table[0]) == 0 {
+			if result.Stop != nil {
+				result.Stop()
+			}
+			return errNoColumns
+		}
+	}
+
+	return nil
+}
+
 func (m model) Init() tea.Cmd {
 	return tea.Batch(textinput.Blink, m.spinner.Tick)
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	subs := m.liveSubscriptions()
+
+	m, cmd := m.update(msg)
+	m.closeDropped(subs)
+
+	return m, cmd
+}
+
+func (m model) update(msg tea.Msg) (model, tea.Cmd) {
 	cmds := []tea.Cmd{}
 
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyCtrlD:
+		if m.state == replStateConfirmingPaste {
+			if key.Matches(msg, m.keyMap.Quit) {
+				return m, tea.Quit
+			}
+
+			m = m.confirmPasteUpdate(msg)
+			newModel, cmd := m.runQueuedCommand()
+			return newModel, cmd
+		}
+
+		if msg.Paste && !m.interacting() {
+			var handled bool
+			m, handled = m.pasteUpdate(msg)
+			if handled {
+				newModel, cmd := m.runQueuedCommand()
+				return newModel, cmd
+			}
+		}
+
+		switch {
+		case key.Matches(msg, m.keyMap.Quit):
 			if m.state == replStateReadingInput ||
 				m.state == replStateReadingInputAndTable ||
 				m.state == replStateReadingInputAndList ||
+				m.state == replStateReadingInputAndTree ||
 				m.state == replStateExecutingCommand {
 
 				return m, tea.Quit
 			}
 
-		case tea.KeyCtrlUp, tea.KeyCtrlK:
-			if m.listResult != nil {
-				m.state = replStateListInteraction
-				m.listResult.SetInteractiveMode(true)
-			}
+		case m.canGoBack() && key.Matches(msg, m.keyMap.Back):
+			return m.back()
 
-			if m.tableResult != nil {
-				m.state = replStateTableInteraction
-				m.tableResult.SetInteractiveMode(true)
-			}
+		case key.Matches(msg, m.keyMap.EnterInteraction):
+			var cmd tea.Cmd
+			m, cmd = m.enterInteraction()
+			cmds = append(cmds, cmd)
 
-		case tea.KeyCtrlDown, tea.KeyCtrlJ, tea.KeyCtrlC:
+		case key.Matches(msg, m.keyMap.LeaveInteraction):
 			if m.listResult != nil {
 				m.state = replStateReadingInputAndList
 				m.listResult.SetInteractiveMode(false)
@@ -215,41 +525,36 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				cmds = append(cmds, cmd)
 			}
 
-		case tea.KeyEnter:
-			if m.state == replStateReadingInputAndList && m.textInput.Value() != "" {
-				// if the list was not used, print it and remove it
-				cmd := tea.Println(m.listResult.View())
-				cmds = append(cmds, cmd)
-				m.listResult = nil
-				m.state = replStateReadingInput
-			}
-
-			if m.state == replStateReadingInputAndTable && m.textInput.Value() != "" {
-				// if the table was not used, print it and remove it
-				cmd := tea.Println(m.tableResult.View())
-				cmds = append(cmds, cmd)
-				m.tableResult = nil
-				m.state = replStateReadingInput
+			if m.treeResult != nil {
+				m.state = replStateReadingInputAndTree
+				m.treeResult.SetInteractiveMode(false)
 			}
 
-		default:
-			if msg.String() == "q" {
-				switch m.state {
-				case replStateTableInteraction:
+		case key.Matches(msg, m.keyMap.QuitInteraction):
+			switch m.state {
+			case replStateTableInteraction:
+				if !m.tableResult.SettingFilter() && !m.tableResult.Saving() {
 					m.state = replStateReadingInputAndTable
 					m.tableResult.SetInteractiveMode(false)
 					newTable, cmd := m.tableResult.Update(msg)
 					m.tableResult = &newTable
 					return m, cmd
+				}
 
-				case replStateListInteraction:
-					if !m.listResult.SettingFilter() {
-						m.state = replStateReadingInputAndList
-						m.listResult.SetInteractiveMode(false)
-						newList, cmd := m.listResult.Update(msg)
-						m.listResult = &newList
-						return m, cmd
-					}
+			case replStateListInteraction:
+				if !m.listResult.SettingFilter() {
+					m.state = replStateReadingInputAndList
+					m.listResult.SetInteractiveMode(false)
+					newList, cmd := m.listResult.Update(msg)
+					m.listResult = &newList
+					return m, cmd
+				}
+
+			case replStateTreeInteraction:
+				if !m.treeResult.SettingFilter() {
+					m.state = replStateReadingInputAndTree
+					m.treeResult.SetInteractiveMode(false)
+					return m, nil
 				}
 			}
 		}
@@ -261,30 +566,111 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case commandError:
 		output := fmt.Sprintf("ERROR: %v", msg)
 		cmds = append(cmds, tea.Println(output))
+		m.resultStack = nil
+		m.drillingDown = false
 		m.listResult = nil
 		m.tableResult = nil
+		m.treeResult = nil
 		m.state = replStateReadingInput
 
 	case commandExecuted:
-		if m.historyFile != "" {
-			cmds = append(cmds, func() tea.Msg {
-				hf, err := os.OpenFile(m.historyFile, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
-				if err != nil {
-					return commandError(err)
-				}
-				defer hf.Close()
+		m.commandStart = time.Now()
+		m.resultRoot = string(msg)
 
-				if _, err := hf.WriteString(string(msg) + "\n"); err != nil {
-					return commandError(err)
-				}
+		command, ok := m.redactFn(string(msg))
+		if !ok {
+			break
+		}
 
-				return nil
-			})
+		m.history = append(m.history, historyEntry{
+			command: command,
+			time:    time.Now(),
+			status:  historyEntryStatusRunning,
+			run:     string(msg),
+		})
+
+		historyStore := m.historyStore
+		cmds = append(cmds, func() tea.Msg {
+			if err := historyStore.Append(command); err != nil {
+				return commandError(err)
+			}
+
+			return nil
+		})
+
+	case commandFinished:
+		// built-ins are applied even if their result is saved to a file
+		var result tea.Msg
+		m, result = m.resolveBuiltin(msg.result)
+		if msg.redirectTo == "" {
+			result = checkActions(result, m.keyMap)
+		}
+
+		_, failed := result.(commandError)
+		m.chain = nextSteps(msg.chain, failed)
+
+		// the entry of the command line runs until its last command does.
+		// It is not running if the command was kept out of the history.
+		if len(m.chain) == 0 && len(m.history) > 0 && m.history[len(m.history)-1].status == historyEntryStatusRunning {
+			last := &m.history[len(m.history)-1]
+			last.status = historyEntryStatusOK
+			if failed {
+				last.status = historyEntryStatusFailed
+			}
+		}
+
+		// pasted commands stop at the first failure
+		if failed && len(m.chain) == 0 {
+			m.pasteQueue = nil
+		}
+
+		m.commandsRun++
+		m.lastInfo = PromptInfo{
+			CommandsRun:  m.commandsRun,
+			LastFailed:   failed,
+			LastDuration: time.Since(m.commandStart),
+			Now:          time.Now(),
 		}
 
+		m = m.refreshPrompt()
+
+		if msg.redirectTo != "" && !failed {
+			result = CommandResultSaveTo{File: msg.redirectTo, Result: result}
+		}
+
+		cmds = append(cmds, func() tea.Msg {
+			return result
+		})
+
+	case refreshStatusBar:
+		if m.statusBarFunc != nil {
+			info := m.lastInfo
+			info.Now = time.Now()
+			m.statusBar = m.statusBarFunc(info)
+		}
+
+	case resultSelected:
+		// callbacks of the items can change the state shown in the prompt,
+		// e.g. the selected context
+		msg.result = checkActions(msg.result, m.keyMap)
+		_, failed := msg.result.(commandError)
+		m.lastInfo.LastFailed = failed
+		m.lastInfo.Now = time.Now()
+		m = m.refreshPrompt()
+
+		var cmd tea.Cmd
+		m, cmd = m.resultSelectedUpdate(msg)
+		cmds = append(cmds, cmd)
+
+	case execCommand:
+		var cmd tea.Cmd
+		m, cmd = m.execInput(string(msg), false)
+		cmds = append(cmds, cmd)
+
 	case CommandResultEmpty:
 		m.listResult = nil
 		m.tableResult = nil
+		m.treeResult = nil
 		m.state = replStateReadingInput
 
 	case CommandResultSimple:
@@ -294,26 +680,189 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		m.listResult = nil
 		m.tableResult = nil
+		m.treeResult = nil
 		m.state = replStateReadingInput
 
 	case CommandResultList:
-		l := newList(msg.List, msg.OnSelect, m.width, m.height)
+		l := newList(msg.items(), msg.OnSelect, msg.OnSelectMany, msg.Actions, m.width, m.height, m.keyMap)
+		if msg.Preview != nil {
+			l.setPreview(msg.Preview)
+		}
 		m.listResult = &l
 		m.tableResult = nil
+		m.treeResult = nil
 		m.state = replStateReadingInputAndList
 
 	case CommandResultTable:
-		table := newTable(msg.Table, msg.OnSelect, m.width, m.height)
+		table := newTable(msg.Table, msg.OnSelect, msg.Actions, m.width, m.height, m.keyMap)
+		if msg.Preview != nil {
+			table.setPreview(msg.Preview)
+		}
 		m.listResult = nil
 		m.tableResult = &table
+		m.treeResult = nil
 		m.state = replStateReadingInputAndTable
 
-	case CommandResultSaveTo:
+	case CommandResultPagedList:
+		// the REPL keeps executing the command until the first page is
+		// fetched
+		p := newPager(msg.pageSize(), msg.Total)
+		cmds = append(cmds, fetchListPage(msg, p, 0))
+
+	case listPageLoaded:
+		msg.pager.pageLoaded(len(msg.items), msg.err)
+
+		if msg.offset == 0 {
+			if msg.err != nil {
+				err := msg.err
+				cmds = append(cmds, func() tea.Msg {
+					return commandError(err)
+				})
+				break
+			}
+
+			result, p := msg.result, msg.pager
+			l := newList(msg.items, result.OnSelect, result.OnSelectMany, result.Actions, m.width, m.height, m.keyMap)
+			l.setPager(p, func(offset int) tea.Cmd {
+				return fetchListPage(result, p, offset)
+			})
+			if result.Preview != nil {
+				l.setPreview(result.Preview)
+			}
+			m.listResult = &l
+			m.tableResult = nil
+			m.treeResult = nil
+			m.state = replStateReadingInputAndList
+			break
+		}
+
+		// pages of a previous result are dropped
+		if m.listResult != nil && m.listResult.pager == msg.pager && msg.err == nil {
+			l, cmd := m.listResult.appendPage(msg.items)
+			m.listResult = &l
+			cmds = append(cmds, cmd)
+		}
+
+	case CommandResultPagedTable:
+		p := newPager(msg.pageSize(), msg.Total)
+		cmds = append(cmds, fetchTablePage(msg, p, 0))
+
+	case tablePageLoaded:
+		msg.pager.pageLoaded(len(msg.rows), msg.err)
+
+		if msg.offset == 0 {
+			if msg.err != nil {
+				err := msg.err
+				cmds = append(cmds, func() tea.Msg {
+					return commandError(err)
+				})
+				break
+			}
+
+			result, p := msg.result, msg.pager
+			rows := append([][]string{result.Header}, msg.rows...)
+			table := newTable(rows, result.OnSelect, result.Actions, m.width, m.height, m.keyMap)
+			table.setPager(p, func(offset int) tea.Cmd {
+				return fetchTablePage(result, p, offset)
+			})
+			if result.Preview != nil {
+				table.setPreview(result.Preview)
+			}
+			m.listResult = nil
+			m.tableResult = &table
+			m.treeResult = nil
+			m.state = replStateReadingInputAndTable
+			break
+		}
+
+		if m.tableResult != nil && m.tableResult.pager == msg.pager && msg.err == nil {
+			table := m.tableResult.appendPage(msg.rows)
+			m.tableResult = &table
+		}
+
+	case CommandResultLiveList:
+		l := newList(msg.Items, msg.OnSelect, nil, msg.Actions, m.width, m.height, m.keyMap)
+		l.live = &liveSubscription{stop: msg.Stop}
+		if msg.Preview != nil {
+			l.setPreview(msg.Preview)
+		}
+		m.listResult = &l
+		m.tableResult = nil
+		m.treeResult = nil
+		m.state = replStateReadingInputAndList
+		cmds = append(cmds, waitForListUpdate(l.live, msg.Updates))
+
+	case liveListUpdate:
+		// the list was replaced by the result of another command
+		l := m.liveList(msg.sub)
+		if l == nil {
+			msg.sub.close()
+			break
+		}
+
+		newList, cmd := l.replaceItems(msg.items)
+		*l = newList
+		cmds = append(cmds, cmd, waitForListUpdate(msg.sub, msg.updates))
+
+	case CommandResultLiveTable:
+		table := newTable(msg.Table, msg.OnSelect, msg.Actions, m.width, m.height, m.keyMap)
+		table.live = &liveSubscription{stop: msg.Stop}
+		table.keyColumn = msg.KeyColumn
+		if msg.Preview != nil {
+			table.setPreview(msg.Preview)
+		}
+		m.listResult = nil
+		m.tableResult = &table
+		m.treeResult = nil
+		m.state = replStateReadingInputAndTable
+		cmds = append(cmds, waitForTableUpdate(table.live, msg.Updates))
+
+	case liveTableUpdate:
+		table := m.liveTable(msg.sub)
+		if table == nil {
+			msg.sub.close()
+			break
+		}
+
+		newTable, cmd := table.replaceRows(msg.rows)
+		*table = newTable
+		cmds = append(cmds, cmd, waitForTableUpdate(msg.sub, msg.updates))
+
+	case CommandResultTree:
+		tree := newTree(msg.Nodes, m.width, m.height, m.keyMap)
 		m.listResult = nil
 		m.tableResult = nil
-		m.state = replStateReadingInput
+		m.treeResult = &tree
+		m.state = replStateReadingInputAndTree
+
+	case treeChildrenLoaded:
+		msg.node.childrenLoaded(msg.children, msg.err)
+
+		// the tree may be hidden by a drill-down, or being selected from
+		trees := []*replTree{m.treeResult, m.selectedFrom.tree}
+		for _, frame := range m.resultStack {
+			trees = append(trees, frame.tree)
+		}
+		for _, tree := range trees {
+			if tree != nil && tree.owns(msg.node) {
+				tree.refreshRows()
+			}
+		}
+
+	case CommandResultSaveTo:
+		// interactive tables save their rows while they are still shown
+		if m.state == replStateExecutingCommand {
+			m.listResult = nil
+			m.tableResult = nil
+			m.treeResult = nil
+			m.state = replStateReadingInput
+		}
 		var content string
 
+		// paged results and lazy trees are fetched with the file written,
+		// out of Update
+		var fetchContent func() (string, error)
+
 		switch msg := msg.Result.(type) {
 		case CommandResultEmpty:
 			break
@@ -322,15 +871,69 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			content = string(msg)
 
 		case CommandResultList:
-			l := newList(msg.List, msg.OnSelect, m.width, len(msg.List))
+			l := newList(msg.items(), nil, nil, nil, m.width, math.MaxInt, m.keyMap)
 			content = l.View()
 
 		case CommandResultTable:
-			table := newTable(msg.Table, msg.OnSelect, m.width, len(msg.Table))
+			table := newTable(msg.Table, nil, nil, m.width, math.MaxInt, m.keyMap)
+			content = table.View()
+
+		case CommandResultLiveList:
+			// only the first items are saved
+			if msg.Stop != nil {
+				msg.Stop()
+			}
+
+			l := newList(msg.Items, nil, nil, nil, m.width, math.MaxInt, m.keyMap)
+			content = l.View()
+
+		case CommandResultLiveTable:
+			if msg.Stop != nil {
+				msg.Stop()
+			}
+
+			table := newTable(msg.Table, nil, nil, m.width, math.MaxInt, m.keyMap)
 			content = table.View()
+
+		case CommandResultTree:
+			fetchContent = func() (string, error) {
+				return treeText(msg.Nodes)
+			}
+
+		case CommandResultPagedList:
+			width, keyMap := m.width, m.keyMap
+			fetchContent = func() (string, error) {
+				items, err := msg.fetchAll()
+				if err != nil {
+					return "", err
+				}
+
+				l := newList(items, nil, nil, nil, width, math.MaxInt, keyMap)
+				return l.View(), nil
+			}
+
+		case CommandResultPagedTable:
+			width, keyMap := m.width, m.keyMap
+			fetchContent = func() (string, error) {
+				rows, err := msg.fetchAll()
+				if err != nil {
+					return "", err
+				}
+
+				table := newTable(rows, nil, nil, width, math.MaxInt, keyMap)
+				return table.View(), nil
+			}
 		}
 
 		cmds = append(cmds, func() tea.Msg {
+			if fetchContent != nil {
+				var err error
+				content, err = fetchContent()
+				if err != nil {
+					return commandError(err)
+				}
+			}
+
 			err := os.WriteFile(msg.File, []byte(content), 0600)
 			if err != nil {
 				return commandError(err)
@@ -344,18 +947,33 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmds = append(cmds, cmd)
 	}
 
+	// results of a drill-down keep the focus once they are shown
+	if m.drillingDown && m.state != replStateExecutingCommand {
+		m.drillingDown = false
+
+		var cmd tea.Cmd
+		m, cmd = m.enterInteraction()
+		cmds = append(cmds, cmd)
+	}
+
 	switch m.state {
 	case replStateReadingInput,
 		replStateReadingInputAndList,
-		replStateReadingInputAndTable:
+		replStateReadingInputAndTable,
+		replStateReadingInputAndTree:
 
 		var cmd tea.Cmd
 		m.textInput, cmd = m.textInput.Update(msg)
-		cmds = append(cmds, cmd)
 
+		// the input is only run once it is valid, so the result shown is
+		// kept while it is fixed
 		if m.textInput.ExecutedCommand() {
+			var printResult tea.Cmd
+			m, printResult = m.dropResult()
+			cmd = tea.Sequence(printResult, cmd)
 			m.state = replStateExecutingCommand
 		}
+		cmds = append(cmds, cmd)
 
 	case replStateListInteraction:
 		newList, cmd := m.listResult.Update(msg)
@@ -364,6 +982,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		if m.listResult.ExecutedCommand() {
 			m.state = replStateExecutingCommand
+			m.selectedFrom = resultFrame{list: m.listResult}
 			m.listResult = nil
 		}
 
@@ -374,16 +993,125 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		if m.tableResult.ExecutedCommand() {
 			m.state = replStateExecutingCommand
+			m.selectedFrom = resultFrame{table: m.tableResult}
 			m.tableResult = nil
 		}
+
+	case replStateTreeInteraction:
+		newTree, cmd := m.treeResult.Update(msg)
+		m.treeResult = &newTree
+		cmds = append(cmds, cmd)
+
+		if m.treeResult.ExecutedCommand() {
+			m.state = replStateExecutingCommand
+			m.selectedFrom = resultFrame{tree: m.treeResult}
+			m.treeResult = nil
+		}
 	}
 
+	var cmd tea.Cmd
+	m, cmd = m.runChainedCommand()
+	cmds = append(cmds, cmd)
+
+	m, cmd = m.runQueuedCommand()
+	cmds = append(cmds, cmd)
+
 	return m, tea.Batch(cmds...)
 }
 
+// interacting returns true if the focus is on the result instead of the
+// prompt.
+func (m model) interacting() bool {
+	return m.state == replStateListInteraction ||
+		m.state == replStateTableInteraction ||
+		m.state == replStateTreeInteraction
+}
+
+// enterInteraction moves the focus to the result shown, requesting the
+// preview of the item under the cursor if it has one.
+func (m model) enterInteraction() (model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	if m.listResult != nil {
+		m.state = replStateListInteraction
+		m.listResult.SetInteractiveMode(true)
+		cmd = m.listResult.updatePreview(nil)
+	}
+
+	if m.tableResult != nil {
+		m.state = replStateTableInteraction
+		m.tableResult.SetInteractiveMode(true)
+		cmd = m.tableResult.updatePreview(nil)
+	}
+
+	if m.treeResult != nil {
+		m.state = replStateTreeInteraction
+		m.treeResult.SetInteractiveMode(true)
+	}
+
+	return m, cmd
+}
+
+// dropResult removes the results before running a new command. The result
+// shown under the prompt is printed, since it was not interacted with.
+func (m model) dropResult() (model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch m.state {
+	case replStateReadingInputAndList:
+		cmd = tea.Println(m.withBreadcrumb(m.listResult.View()))
+	case replStateReadingInputAndTable:
+		cmd = tea.Println(m.withBreadcrumb(m.tableResult.View()))
+	case replStateReadingInputAndTree:
+		cmd = tea.Println(m.withBreadcrumb(m.treeResult.View()))
+	}
+
+	m.listResult = nil
+	m.tableResult = nil
+	m.treeResult = nil
+	m.resultStack = nil
+	m.state = replStateReadingInput
+
+	return m, cmd
+}
+
+// execInput runs a command as if it had been typed in the prompt, printing
+// the result of the previous command if it is still shown. Continued
+// commands are echoed after the continuation prompt.
+func (m model) execInput(command string, continued bool) (model, tea.Cmd) {
+	var valid bool
+	m.textInput, valid = m.textInput.validateCommand(command)
+	if !valid {
+		// the rest of the pasted commands are dropped, as when one fails
+		m.pasteQueue = nil
+		return m, nil
+	}
+
+	cmds := []tea.Cmd{}
+
+	var printResult tea.Cmd
+	m, printResult = m.dropResult()
+	cmds = append(cmds, printResult)
+
+	var cmd tea.Cmd
+	if continued {
+		m.textInput, cmd = m.textInput.ExecContinued(command)
+	} else {
+		m.textInput, cmd = m.textInput.Exec(command)
+	}
+	cmds = append(cmds, cmd)
+	m.state = replStateExecutingCommand
+
+	return m, tea.Sequence(cmds...)
+}
+
 func (m model) View() string {
 	view := ""
 
+	if breadcrumb := m.breadcrumb(); breadcrumb != "" && m.state != replStateExecutingCommand {
+		view += breadcrumb + "\n"
+	}
+
 	if m.listResult != nil {
 		view += m.listResult.View() + "\n"
 	}
@@ -392,13 +1120,19 @@ func (m model) View() string {
 		view += m.tableResult.View() + "\n"
 	}
 
+	if m.treeResult != nil {
+		view += m.treeResult.View() + "\n"
+	}
+
 	if m.state == replStateExecutingCommand {
 		view += fmt.Sprintf("%s executing...\n", m.spinner.View())
+	} else if m.state == replStateConfirmingPaste {
+		view += m.confirmPasteView() + "\n"
 	} else {
 		view += m.textInput.View() + "\n"
 	}
 
-	return view
+	return m.withStatusBar(view)
 }
 
 type commandError error
@@ -413,13 +1147,71 @@ type CommandResultEmpty struct{}
 type CommandResultSimple string
 
 type CommandResultList struct {
-	List     []string
+	List []string
+
+	// Items is used instead of List to show items with a description, an
+	// icon or a value different from their title.
+	Items []ListItem
+
+	// OnSelect receives the Value of the selected item, or its title if it
+	// has no value.
 	OnSelect func(selected string) interface{}
+
+	// OnSelectMany allows selecting several items, and is called with all of
+	// them instead of OnSelect.
+	OnSelectMany func(selected []string) interface{}
+
+	// Actions are run on the item under the cursor with their own keys.
+	Actions []ListAction
+
+	// Preview is shown under the list for the item under the cursor in
+	// interactive mode. It receives the same value as OnSelect, and is run
+	// in the background once per item.
+	Preview func(selected string) string
+}
+
+func (r CommandResultList) items() []ListItem {
+	if len(r.Items) > 0 {
+		return r.Items
+	}
+
+	items := make([]ListItem, len(r.List))
+	for i, title := range r.List {
+		items[i] = ListItem{Title: title}
+	}
+
+	return items
 }
 
 type CommandResultTable struct {
 	Table    [][]string
 	OnSelect func(selected []string) interface{}
+
+	// Actions are run on the row under the cursor with their own keys.
+	Actions []TableAction
+
+	// Preview is shown under the table for the row under the cursor in
+	// interactive mode. It is run in the background once per row.
+	Preview func(selected []string) string
+}
+
+// CommandResultTree shows hierarchical data. Nodes are expanded and
+// collapsed in interactive mode, and shown indented when printed. Saving the
+// tree or running it non-interactively loads the children of every node.
+type CommandResultTree struct {
+	Nodes []TreeNode
 }
 
 type commandExecuted string
+
+// commandFinished wraps the result of a command typed in the prompt, so the
+// model can record its outcome before handling the result itself.
+type commandFinished struct {
+	result tea.Msg
+
+	// chain are the commands of the command line after this one
+	chain []chainStep
+
+	// redirectTo is the file the result is saved to, if it was redirected
+	redirectTo string
+}
@@ -0,0 +1,89 @@
+package vorl
+
+import (
+	"errors"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// InputValidator can be implemented by an Interpreter to check the input
+// when Enter is pressed. Invalid input is not run nor stored in the history,
+// and the error is shown under the prompt.
+type InputValidator interface {
+	Validate(input string) error
+}
+
+// ValidationError can be returned by InputValidator.Validate to move the
+// cursor to the position of the error.
+type ValidationError struct {
+	Message string
+
+	// Position is the byte offset of the error in the input.
+	Position int
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+var validationErrorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("160"))
+
+// validate checks the input, moving the cursor to the error position if
+// there is one. It returns false if the input is not valid.
+func (ri replInput) validate(input string) (replInput, bool) {
+	ri.validationErr = nil
+	if ri.validateFn == nil {
+		return ri, true
+	}
+
+	err := ri.validateFn(input)
+	if err == nil {
+		return ri, true
+	}
+
+	return ri.rejectInput(input, err), false
+}
+
+// rejectInput shows the error of the input in the prompt.
+func (ri replInput) rejectInput(input string, err error) replInput {
+	ri.validationErr = err
+	ri.invalidInput = input
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		offset := max(0, min(validationErr.Position, len(input)))
+		ri.textInput.SetCursor(len([]rune(input[:offset])))
+	}
+
+	return ri
+}
+
+// validateCommand checks a command that is run without being typed, e.g. a
+// pasted one. An invalid command is left in the prompt with the error, so it
+// can be fixed.
+func (ri replInput) validateCommand(command string) (replInput, bool) {
+	ri.validationErr = nil
+	if ri.validateFn == nil {
+		return ri, true
+	}
+
+	err := ri.validateFn(command)
+	if err == nil {
+		return ri, true
+	}
+
+	ri.pushUndo([]rune(ri.textInput.Value()), ri.textInput.Position())
+	ri.textInput.SetValue(command)
+	ri.textInput.CursorEnd()
+
+	return ri.rejectInput(command, err), false
+}
+
+// validationErrorView shows the error until the input is changed.
+func (ri replInput) validationErrorView() string {
+	if ri.validationErr == nil || ri.textInput.Value() != ri.invalidInput {
+		return ""
+	}
+
+	return "\n" + validationErrorStyle.Render("✗ "+ri.validationErr.Error())
+}
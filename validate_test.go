@@ -0,0 +1,79 @@
+package vorl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type validatingInterpreter struct{}
+
+func (validatingInterpreter) Exec(string) (interface{}, error) { return nil, nil }
+func (validatingInterpreter) Suggest(string) []string          { return nil }
+
+func (validatingInterpreter) Validate(input string) error {
+	if strings.HasPrefix(input, "bad") {
+		return errors.New("bad input")
+	}
+	return nil
+}
+
+func newValidatingModel(t *testing.T) model {
+	t.Helper()
+
+	r, err := NewREPL(validatingInterpreter{}, ">", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModel, _ := r.model.Update(CommandResultTable{Table: [][]string{{"a"}, {"1"}}})
+	return newModel.(model)
+}
+
+func TestInvalidInputKeepsResult(t *testing.T) {
+	m := newValidatingModel(t)
+
+	for _, msg := range []tea.KeyMsg{runes("bad"), {Type: tea.KeyEnter}} {
+		newModel, _ := m.Update(msg)
+		m = newModel.(model)
+	}
+
+	if m.state != replStateReadingInputAndTable || m.tableResult == nil {
+		t.Errorf("the table was dropped by an invalid input")
+	}
+	if m.textInput.validationErr == nil {
+		t.Errorf("the validation error is not shown")
+	}
+}
+
+func TestInvalidQueuedCommandIsNotRun(t *testing.T) {
+	m := newValidatingModel(t)
+	m.pasteQueue = []string{"bad one", "good one"}
+
+	m, cmd := m.runQueuedCommand()
+
+	if cmd != nil || m.state != replStateReadingInputAndTable {
+		t.Errorf("an invalid pasted command was run")
+	}
+	if len(m.pasteQueue) != 0 {
+		t.Errorf("pasted commands after an invalid one are still queued: %v", m.pasteQueue)
+	}
+	if got := m.textInput.Value(); got != "bad one" {
+		t.Errorf("input = %q, want the invalid command", got)
+	}
+}
+
+func TestValidInputReplacesResult(t *testing.T) {
+	m := newValidatingModel(t)
+
+	for _, msg := range []tea.KeyMsg{runes("good"), {Type: tea.KeyEnter}} {
+		newModel, _ := m.Update(msg)
+		m = newModel.(model)
+	}
+
+	if m.state != replStateExecutingCommand || m.tableResult != nil {
+		t.Errorf("state = %v, table = %v, want the command running", m.state, m.tableResult)
+	}
+}
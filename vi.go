@@ -0,0 +1,559 @@
+package vorl
+
+import (
+	"unicode"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type viMode int
+
+const (
+	viModeInsert viMode = iota
+	viModeNormal
+	viModeSearch
+)
+
+type viResult int
+
+const (
+	viResultIncomplete viResult = iota
+	viResultInvalid
+	viResultDone
+	viResultChange
+	viResultInsert
+)
+
+// viChange is the last change done in normal mode, repeated with '.'.
+type viChange struct {
+	keys   string
+	insert string
+}
+
+// viState holds the state of the vi editing mode of the input.
+type viState struct {
+	enabled bool
+	mode    viMode
+
+	// pending are the keys of the normal mode command being typed
+	pending string
+
+	register string
+
+	lastChange viChange
+
+	// insertKeys is the command that entered insert mode, and inserted the
+	// text typed since then, so the whole change can be repeated
+	insertKeys string
+	inserted   []rune
+
+	searchBackward bool
+	searchInput    string
+	searchResults  []string
+	searchIndex    int
+}
+
+func (ri *replInput) SetViMode(enabled bool) {
+	ri.vi = viState{
+		enabled:  enabled,
+		register: ri.vi.register,
+	}
+}
+
+// viUpdate handles key presses when vi mode is enabled. It returns false if
+// the key has to be handled as in emacs mode.
+func (ri replInput) viUpdate(msg tea.KeyMsg) (replInput, tea.Cmd, bool) {
+	switch ri.vi.mode {
+	case viModeInsert:
+		switch msg.Type {
+		case tea.KeyEsc:
+			ri = ri.viExitInsert()
+			return ri, nil, true
+
+		case tea.KeyRunes:
+			ri.vi.inserted = append(ri.vi.inserted, msg.Runes...)
+
+		case tea.KeySpace:
+			ri.vi.inserted = append(ri.vi.inserted, ' ')
+
+		case tea.KeyBackspace:
+			if len(ri.vi.inserted) > 0 {
+				ri.vi.inserted = ri.vi.inserted[:len(ri.vi.inserted)-1]
+			}
+		}
+
+		return ri, nil, false
+
+	case viModeSearch:
+		return ri.viSearchUpdate(msg)
+	}
+
+	// pasted text is inserted even in normal mode
+	if msg.Paste {
+		ri.vi.pending = ""
+		return ri, nil, false
+	}
+
+	switch {
+	case key.Matches(msg, ri.keyMap.Execute):
+		ri.vi.pending = ""
+		ri.vi.mode = viModeInsert
+		return ri, nil, false
+
+	case key.Matches(msg, ri.keyMap.HistoryPrevious, ri.keyMap.HistoryNext,
+		ri.keyMap.ClearInput, ri.keyMap.ReverseSearch,
+		ri.keyMap.EditorPrefix, ri.keyMap.EditInEditor):
+		ri.vi.pending = ""
+		return ri, nil, false
+	}
+
+	switch msg.Type {
+	case tea.KeyLeft, tea.KeyRight, tea.KeyHome, tea.KeyEnd:
+		ri.vi.pending = ""
+		return ri, nil, false
+
+	case tea.KeyEsc:
+		ri.vi.pending = ""
+		return ri, nil, true
+
+	case tea.KeyRunes:
+		ri.vi.pending += string(msg.Runes)
+
+	case tea.KeySpace:
+		ri.vi.pending += " "
+
+	default:
+		return ri, nil, true
+	}
+
+	keys := ri.vi.pending
+
+	// k and j navigate the history as the arrow keys do
+	if keys == "k" || keys == "j" {
+		ri.vi.pending = ""
+		if keys == "k" {
+			ri = ri.historyPrevious()
+		} else {
+			ri = ri.historyNext()
+		}
+		ri.textInput.SetCursor(0)
+		return ri, nil, true
+	}
+
+	var result viResult
+	ri, result = ri.viCommand(keys)
+
+	switch result {
+	case viResultIncomplete:
+		return ri, nil, true
+
+	case viResultChange:
+		ri.vi.lastChange = viChange{keys: keys}
+
+	case viResultInsert:
+		ri.vi.insertKeys = keys
+		ri.vi.inserted = nil
+	}
+
+	ri.vi.pending = ""
+	return ri, nil, true
+}
+
+// viCommand runs a normal mode command.
+func (ri replInput) viCommand(keys string) (replInput, viResult) {
+	value := []rune(ri.textInput.Value())
+	pos := ri.textInput.Position()
+	k := []rune(keys)
+
+	switch k[0] {
+	case 'i':
+		return ri.viEnterInsert(pos), viResultInsert
+
+	case 'a':
+		return ri.viEnterInsert(min(pos+1, len(value))), viResultInsert
+
+	case 'I':
+		return ri.viEnterInsert(0), viResultInsert
+
+	case 'A':
+		return ri.viEnterInsert(len(value)), viResultInsert
+
+	case 'x':
+		return ri.viDelete(value, pos, min(pos+1, len(value)), pos), viResultChange
+
+	case 'X':
+		return ri.viDelete(value, max(pos-1, 0), pos, max(pos-1, 0)), viResultChange
+
+	case 'D':
+		return ri.viCommand("d$")
+
+	case 'C':
+		return ri.viCommand("c$")
+
+	case 's':
+		return ri.viCommand("cl")
+
+	case 'S':
+		return ri.viCommand("cc")
+
+	case 'p', 'P':
+		if ri.vi.register == "" {
+			return ri, viResultInvalid
+		}
+		at := pos
+		if k[0] == 'p' && len(value) > 0 {
+			at = min(pos+1, len(value))
+		}
+		text := []rune(ri.vi.register)
+		newValue := append([]rune{}, value[:at]...)
+		newValue = append(newValue, text...)
+		newValue = append(newValue, value[at:]...)
+		ri = ri.setValue(newValue, at+len(text)-1, value, pos, editActionNone)
+		return ri, viResultChange
+
+	case 'u':
+		ri = ri.undo()
+		ri.viClampCursor()
+		return ri, viResultDone
+
+	case '.':
+		return ri.viRepeat(), viResultDone
+
+	case '/', '?':
+		ri.vi.mode = viModeSearch
+		ri.vi.searchBackward = k[0] == '/'
+		ri.vi.searchInput = ""
+		return ri, viResultDone
+
+	case 'n', 'N':
+		return ri.viNextSearchResult(k[0] == 'N'), viResultDone
+
+	case 'd', 'c', 'y':
+		if len(k) == 1 {
+			return ri, viResultIncomplete
+		}
+
+		start, end := 0, len(value)
+		if k[1] != k[0] {
+			motion := k[1:]
+			// cw behaves as ce when the cursor is on a word
+			if k[0] == 'c' && motion[0] == 'w' && pos < len(value) && !unicode.IsSpace(value[pos]) {
+				motion = []rune{'e'}
+			}
+
+			target, inclusive, result := viMotion(value, pos, motion)
+			if result != viResultDone {
+				return ri, result
+			}
+
+			start, end = min(pos, target), max(pos, target)
+			if inclusive {
+				end = min(end+1, len(value))
+			}
+		}
+
+		ri.vi.register = string(value[start:end])
+
+		switch k[0] {
+		case 'd':
+			return ri.viDelete(value, start, end, start), viResultChange
+
+		case 'c':
+			ri = ri.viDelete(value, start, end, start)
+			return ri.viEnterInsert(start), viResultInsert
+
+		default:
+			ri.textInput.SetCursor(start)
+			return ri, viResultDone
+		}
+	}
+
+	target, _, result := viMotion(value, pos, k)
+	if result == viResultDone {
+		ri.textInput.SetCursor(target)
+		ri.viClampCursor()
+	}
+
+	return ri, result
+}
+
+// viMotion returns the position the cursor moves to, and whether the
+// character at that position is included when the motion is used with an
+// operator.
+func viMotion(value []rune, pos int, keys []rune) (int, bool, viResult) {
+	switch keys[0] {
+	case 'h':
+		return max(pos-1, 0), false, viResultDone
+
+	case 'l':
+		return min(pos+1, len(value)), false, viResultDone
+
+	case '0':
+		return 0, false, viResultDone
+
+	case '^':
+		i := 0
+		for i < len(value) && unicode.IsSpace(value[i]) {
+			i++
+		}
+		return i, false, viResultDone
+
+	case '$':
+		return max(len(value)-1, 0), true, viResultDone
+
+	case 'w':
+		i := pos
+		if i < len(value) && !unicode.IsSpace(value[i]) {
+			class := viCharClass(value[i])
+			for i < len(value) && viCharClass(value[i]) == class {
+				i++
+			}
+		}
+		for i < len(value) && unicode.IsSpace(value[i]) {
+			i++
+		}
+		return i, false, viResultDone
+
+	case 'b':
+		i := pos
+		for i > 0 && unicode.IsSpace(value[i-1]) {
+			i--
+		}
+		if i > 0 {
+			class := viCharClass(value[i-1])
+			for i > 0 && viCharClass(value[i-1]) == class {
+				i--
+			}
+		}
+		return i, false, viResultDone
+
+	case 'e':
+		i := pos + 1
+		for i < len(value) && unicode.IsSpace(value[i]) {
+			i++
+		}
+		if i >= len(value) {
+			return max(len(value)-1, 0), true, viResultDone
+		}
+		class := viCharClass(value[i])
+		for i+1 < len(value) && viCharClass(value[i+1]) == class {
+			i++
+		}
+		return i, true, viResultDone
+
+	case 'f', 't', 'F', 'T':
+		if len(keys) < 2 {
+			return pos, false, viResultIncomplete
+		}
+
+		target := keys[1]
+		switch keys[0] {
+		case 'f', 't':
+			for i := pos + 1; i < len(value); i++ {
+				if value[i] == target {
+					if keys[0] == 't' {
+						i--
+					}
+					return i, true, viResultDone
+				}
+			}
+
+		default:
+			for i := pos - 1; i >= 0; i-- {
+				if value[i] == target {
+					if keys[0] == 'T' {
+						i++
+					}
+					return i, false, viResultDone
+				}
+			}
+		}
+	}
+
+	return pos, false, viResultInvalid
+}
+
+// viCharClass groups characters as vi does to find word boundaries:
+// whitespace, word characters and punctuation.
+func viCharClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func (ri replInput) viDelete(value []rune, start int, end int, pos int) replInput {
+	if start >= end {
+		return ri
+	}
+
+	newValue := append(append([]rune{}, value[:start]...), value[end:]...)
+	ri = ri.setValue(newValue, pos, value, ri.textInput.Position(), editActionNone)
+	ri.viClampCursor()
+
+	return ri
+}
+
+func (ri replInput) viEnterInsert(pos int) replInput {
+	ri.vi.mode = viModeInsert
+	ri.textInput.SetCursor(pos)
+
+	return ri
+}
+
+func (ri replInput) viExitInsert() replInput {
+	ri.vi.mode = viModeNormal
+	if ri.vi.insertKeys != "" {
+		ri.vi.lastChange = viChange{
+			keys:   ri.vi.insertKeys,
+			insert: string(ri.vi.inserted),
+		}
+	}
+	ri.vi.insertKeys = ""
+	ri.vi.inserted = nil
+
+	// as in vi, the cursor moves back onto the last inserted character
+	ri.textInput.SetCursor(ri.textInput.Position() - 1)
+	ri.viClampCursor()
+
+	return ri
+}
+
+// viRepeat runs the last change again, including the text inserted by it.
+func (ri replInput) viRepeat() replInput {
+	change := ri.vi.lastChange
+	if change.keys == "" {
+		return ri
+	}
+
+	var result viResult
+	ri, result = ri.viCommand(change.keys)
+	if result != viResultInsert {
+		return ri
+	}
+
+	value := []rune(ri.textInput.Value())
+	pos := ri.textInput.Position()
+	text := []rune(change.insert)
+
+	newValue := append([]rune{}, value[:pos]...)
+	newValue = append(newValue, text...)
+	newValue = append(newValue, value[pos:]...)
+	ri = ri.setValue(newValue, pos+len(text), value, pos, editActionNone)
+
+	ri.vi.insertKeys = ""
+	ri = ri.viExitInsert()
+	ri.vi.lastChange = change
+
+	return ri
+}
+
+// viClampCursor keeps the cursor on a character, since in normal mode it
+// cannot be after the end of the input.
+func (ri *replInput) viClampCursor() {
+	if ri.vi.mode == viModeNormal && ri.textInput.Position() >= len([]rune(ri.textInput.Value())) {
+		ri.textInput.SetCursor(len([]rune(ri.textInput.Value())) - 1)
+	}
+}
+
+func (ri replInput) viSearchUpdate(msg tea.KeyMsg) (replInput, tea.Cmd, bool) {
+	switch {
+	case key.Matches(msg, ri.keyMap.Execute):
+		ri.vi.mode = viModeNormal
+		if ri.vi.searchInput == "" {
+			break
+		}
+
+		// results are sorted newest first, '?' searches from the oldest one
+		ri.vi.searchResults = ri.searchFn(ri.vi.searchInput)
+		if !ri.vi.searchBackward {
+			results := make([]string, len(ri.vi.searchResults))
+			for i, r := range ri.vi.searchResults {
+				results[len(results)-1-i] = r
+			}
+			ri.vi.searchResults = results
+		}
+		ri.vi.searchIndex = -1
+		ri = ri.viNextSearchResult(false)
+
+	case key.Matches(msg, ri.keyMap.ClearInput), msg.Type == tea.KeyEsc:
+		ri.vi.mode = viModeNormal
+
+	case msg.Type == tea.KeyBackspace:
+		if ri.vi.searchInput == "" {
+			ri.vi.mode = viModeNormal
+			break
+		}
+		r := []rune(ri.vi.searchInput)
+		ri.vi.searchInput = string(r[:len(r)-1])
+
+	case msg.Type == tea.KeyRunes:
+		ri.vi.searchInput += string(msg.Runes)
+
+	case msg.Type == tea.KeySpace:
+		ri.vi.searchInput += " "
+	}
+
+	return ri, nil, true
+}
+
+// viNextSearchResult moves to the next result of the last search, or to
+// the previous one if reverse is true.
+func (ri replInput) viNextSearchResult(reverse bool) replInput {
+	if len(ri.vi.searchResults) == 0 {
+		return ri
+	}
+
+	if reverse {
+		ri.vi.searchIndex = max(ri.vi.searchIndex-1, 0)
+	} else {
+		ri.vi.searchIndex = min(ri.vi.searchIndex+1, len(ri.vi.searchResults)-1)
+	}
+
+	ri.textInput.SetValue(ri.vi.searchResults[ri.vi.searchIndex])
+	ri.textInput.SetCursor(0)
+
+	return ri
+}
+
+func (ri replInput) viView() string {
+	switch ri.vi.mode {
+	case viModeSearch:
+		prefix := "?"
+		if ri.vi.searchBackward {
+			prefix = "/"
+		}
+		return prefix + ri.vi.searchInput
+
+	case viModeNormal:
+		return "[N] " + ri.textInput.View()
+
+	default:
+		return "[I] " + ri.textInput.View()
+	}
+}
+
+const viSetOptionCommandName = "set"
+
+// setEditingMode is sent when the user runs 'set -o vi' or 'set -o emacs'.
+type setEditingMode string
+
+// parseSetEditingMode returns the editing mode chosen with 'set -o', and
+// false if the input is not a call to it.
+func parseSetEditingMode(input string) (string, bool) {
+	fields := Tokenize(input, -1).Values()
+	if len(fields) != 3 || fields[0] != viSetOptionCommandName || fields[1] != "-o" {
+		return "", false
+	}
+
+	if fields[2] != "vi" && fields[2] != "emacs" {
+		return "", false
+	}
+
+	return fields[2], true
+}
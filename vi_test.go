@@ -0,0 +1,104 @@
+package vorl
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestViMotion(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		pos       int
+		keys      string
+		target    int
+		inclusive bool
+		result    viResult
+	}{
+		{"left", "abc", 1, "h", 0, false, viResultDone},
+		{"left at start", "abc", 0, "h", 0, false, viResultDone},
+		{"right", "abc", 1, "l", 2, false, viResultDone},
+		{"start", "  abc", 3, "0", 0, false, viResultDone},
+		{"first non blank", "  abc", 4, "^", 2, false, viResultDone},
+		{"end", "abc", 0, "$", 2, true, viResultDone},
+		{"word", "foo bar", 0, "w", 4, false, viResultDone},
+		{"word punctuation", "foo.bar", 0, "w", 3, false, viResultDone},
+		{"word at end", "foo", 1, "w", 3, false, viResultDone},
+		{"back word", "foo bar", 5, "b", 4, false, viResultDone},
+		{"back word from start of word", "foo bar", 4, "b", 0, false, viResultDone},
+		{"end of word", "foo bar", 0, "e", 2, true, viResultDone},
+		{"end of next word", "foo bar", 2, "e", 6, true, viResultDone},
+		{"find", "a,b,c", 0, "f,", 1, true, viResultDone},
+		{"till", "a,b,c", 0, "t,", 0, true, viResultDone},
+		{"find backward", "a,b,c", 4, "F,", 3, false, viResultDone},
+		{"till backward", "a,b,c", 4, "T,", 4, false, viResultDone},
+		{"find incomplete", "a,b,c", 0, "f", 0, false, viResultIncomplete},
+		{"find not found", "abc", 0, "fz", 0, false, viResultInvalid},
+		{"unknown", "abc", 1, "z", 1, false, viResultInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, inclusive, result := viMotion([]rune(tt.value), tt.pos, []rune(tt.keys))
+			if target != tt.target || inclusive != tt.inclusive || result != tt.result {
+				t.Errorf("viMotion(%q, %d, %q) = %d, %v, %v, want %d, %v, %v",
+					tt.value, tt.pos, tt.keys, target, inclusive, result,
+					tt.target, tt.inclusive, tt.result)
+			}
+		})
+	}
+}
+
+func TestViCommand(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		keys  []tea.KeyMsg
+		want  string
+		pos   int
+	}{
+		{"delete char", "abc", []tea.KeyMsg{runes("0"), runes("x")}, "bc", 0},
+		{"delete word", "foo bar", []tea.KeyMsg{runes("0"), runes("d"), runes("w")}, "bar", 0},
+		{"delete to end", "foo bar", []tea.KeyMsg{runes("0"), runes("w"), runes("D")}, "foo ", 3},
+		{"yank and put", "ab", []tea.KeyMsg{runes("0"), runes("y"), runes("l"), runes("p")}, "aab", 1},
+		{"put before", "ab", []tea.KeyMsg{runes("$"), runes("y"), runes("l"), runes("P")}, "abb", 1},
+		{"undo", "ab", []tea.KeyMsg{runes("0"), runes("x"), runes("u")}, "ab", 0},
+		{
+			// End leaves the cursor after the last rune, where p used to panic
+			"put after end",
+			"ab",
+			[]tea.KeyMsg{runes("0"), runes("y"), runes("l"), {Type: tea.KeyEnd}, runes("p")},
+			"aba",
+			2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ri := newTestInput()
+			ri.SetViMode(true)
+			ri.textInput.SetValue(tt.value)
+			ri = pressKeys(ri, tea.KeyMsg{Type: tea.KeyEsc})
+			ri = pressKeys(ri, tt.keys...)
+
+			if got := ri.textInput.Value(); got != tt.want {
+				t.Errorf("value = %q, want %q", got, tt.want)
+			}
+			if got := ri.textInput.Position(); got != tt.pos {
+				t.Errorf("cursor = %d, want %d", got, tt.pos)
+			}
+		})
+	}
+}
+
+func TestViNormalModeCursorStaysOnInput(t *testing.T) {
+	ri := newTestInput()
+	ri.SetViMode(true)
+	ri.textInput.SetValue("abc")
+	ri = pressKeys(ri, tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyEnd})
+
+	if pos := ri.textInput.Position(); pos != 2 {
+		t.Errorf("cursor = %d, want 2", pos)
+	}
+}